@@ -2,20 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/adapters/handlers/grpchandler"
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/adapters/handlers/httphandler"
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/adapters/importer"
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/adapters/repositories"
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/adapters/repositories/memory"
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/adapters/repositories/mongodb"
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/adapters/repositories/postgres"
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/services"
 	"github.com/aniladanir/bitaksi-casestudy/shared/config"
+	"github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
+	"github.com/aniladanir/bitaksi-casestudy/shared/jwtauth"
 	"github.com/aniladanir/bitaksi-casestudy/shared/log"
+	"github.com/aniladanir/bitaksi-casestudy/shared/tlsconfig"
+	"github.com/aniladanir/bitaksi-casestudy/shared/tracing"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -32,17 +41,54 @@ func main() {
 	// listen os signals and cancel the parent context if one is received.
 	go ListenOsSignal(cancel)
 
-	httpHandler, err := InitializeComponents(errGroupCtx)
+	httpHandler, grpcHandler, batcher, closeDB, err := InitializeComponents(errGroupCtx, errGroup)
 	if err != nil {
 		log.Fatal("encountered error when initializing components", zap.Error(err))
 	}
 
+	// export traces, the w3c trace context is propagated even if tracing is disabled
+	shutdownTracing, err := tracing.Init(errGroupCtx, tracing.Config{
+		Enabled:     config.IsTracingEnabled(),
+		ServiceName: "driver-location-api",
+		Endpoint:    config.GetTracingEndpoint(),
+		Insecure:    config.IsTracingInsecure(),
+		SampleRatio: config.GetTracingSampleRatio(),
+	})
+	if err != nil {
+		log.Fatal("could not initialize tracing", zap.Error(err))
+	}
+
 	// start http handler
 	errGroup.Go(func() error {
 		err := httpHandler.Listen(config.GetHttpServerAddress())
 		return fmt.Errorf("http handler failed listening: %v", err)
 	})
 
+	// start metrics server, it listens apart from the api so that metrics are not exposed publicly
+	metricsServer := httpfiber.NewMetricsServer()
+	errGroup.Go(func() error {
+		err := metricsServer.Listen(config.GetMetricsServerAddress())
+		return fmt.Errorf("metrics server failed listening: %v", err)
+	})
+
+	// start grpc handler
+	errGroup.Go(func() error {
+		err := grpcHandler.Listen(config.GetGrpcServerAddress())
+		return fmt.Errorf("grpc handler failed listening: %v", err)
+	})
+
+	// set serving status of grpc health service
+	errGroup.Go(func() error {
+		return grpcHandler.RunHealthChecks(errGroupCtx)
+	})
+
+	// start location batcher
+	batcherDone := make(chan struct{})
+	errGroup.Go(func() error {
+		defer close(batcherDone)
+		return batcher.Run(errGroupCtx)
+	})
+
 	// gracefully shutdown application if context is canceled
 	errGroup.Go(func() error {
 		<-errGroupCtx.Done()
@@ -53,6 +99,25 @@ func main() {
 			return err
 		}
 
+		// Shut down metrics server
+		if err := metricsServer.Shutdown(); err != nil {
+			return err
+		}
+
+		// Shut down grpc server
+		grpcHandler.Shutdown()
+
+		// close database once pending locations are flushed
+		<-batcherDone
+		closeDB()
+
+		// flush pending spans
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelShutdown()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			return fmt.Errorf("could not shutdown tracing: %w", err)
+		}
+
 		return nil
 	})
 
@@ -64,8 +129,9 @@ func main() {
 	log.Info("greceful shutdown is complete")
 }
 
-// InitializeComponents initalizes all adapters needed by application
-func InitializeComponents(ctx context.Context) (*httphandler.Handler, error) {
+// InitializeComponents initalizes all adapters needed by application, background work is started in errGroup.
+// The returned function closes the database client
+func InitializeComponents(ctx context.Context, errGroup *errgroup.Group) (*httphandler.Handler, *grpchandler.Handler, *services.LocationBatcher, func(), error) {
 	config.Init(*configFile)
 
 	// configure logrotate options
@@ -76,19 +142,12 @@ func InitializeComponents(ctx context.Context) (*httphandler.Handler, error) {
 		GzipArchive: config.GetLogGzipArchive(),
 	}
 
-	// initialize mongo db client
-	mongoClient, err := mongodb.NewMongoClient(config.GetDBConnectionString())
-	if err != nil {
-		return nil, err
-	}
-	mongoDB, err := mongodb.CreateDatabase(ctx, mongoClient, config.GetDBName())
+	// create repositories
+	locationRepo, closeDB, err := NewLocationRepository(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, nil, err
 	}
 
-	// create repositories
-	locationRepo := repositories.NewLocationRepository(mongoDB)
-
 	// create importer
 	csvImporter := importer.NewCsvImporter(locationRepo)
 
@@ -100,33 +159,178 @@ func InitializeComponents(ctx context.Context) (*httphandler.Handler, error) {
 	appLogger := log.NewLoggerWithLogRotate(debug, config.GetLogFile(), logRotateCfg)
 	acccessLogger := log.NewLoggerWithLogRotate(debug, config.GetAccessLogFile(), logRotateCfg)
 
+	// create auth service, tokens are verified if a jwt algorithm is configured
+	var verifier *jwtauth.Verifier
+	if config.GetAuthJwtAlgorithm() != "" {
+		verifier, err = jwtauth.NewVerifier(jwtauth.Config{
+			Algorithm:           config.GetAuthJwtAlgorithm(),
+			Secret:              config.GetAuthJwtSecret(),
+			SecretFile:          config.GetAuthJwtSecretFile(),
+			PublicKeyFile:       config.GetAuthJwtPublicKeyFile(),
+			JWKSURL:             config.GetAuthJwtJWKSUrl(),
+			JWKSRefreshInterval: time.Duration(config.GetAuthJwtJWKSRefreshInterval()) * time.Second,
+			JWKSFile:            config.GetAuthJwtJWKSFile(),
+			Issuer:              config.GetAuthJwtIssuer(),
+			Audience:            config.GetAuthJwtAudience(),
+			Leeway:              time.Duration(config.GetAuthJwtLeeway()) * time.Second,
+		})
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("could not create jwt verifier: %w", err)
+		}
+	}
+	authService := services.NewAuthService(verifier, config.GetAuthMTLSAllowedIdentities())
+
+	// create server tls config, client certificates are verified if a client ca is configured
+	tlsConfig, err := tlsconfig.Server(tlsconfig.Config{
+		CertFile: config.GetTLSCertFile(),
+		KeyFile:  config.GetTLSKeyFile(),
+		CAFile:   config.GetTLSClientCAFile(),
+	})
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("could not create tls config: %w", err)
+	}
+
+	// create rate limiter
+	rateLimiter, err := httpfiber.NewRateLimiterFromConfig(appLogger.With(zap.String("middleware", "ratelimit")))
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	// create location batcher for streamed updates
+	batcher := services.NewLocationBatcher(
+		appLogger.With(zap.String("service", "batcher")),
+		locationService,
+		services.BatcherConfig{
+			FlushInterval:   time.Duration(config.GetStreamFlushIntervalInMs()) * time.Millisecond,
+			BatchSize:       config.GetStreamBatchSize(),
+			MaxPending:      config.GetStreamMaxPending(),
+			MaxFlushRetries: config.GetStreamMaxFlushRetries(),
+		},
+	)
+
+	// import initial coordinates in the background, the api is not ready until the import finishes
+	// and shuts down if it fails so that it is restarted
+	coordinates, err := os.Open(*coordinatesFile)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("could not open coordinates file %s: %w", *coordinatesFile, err)
+	}
+	var imported atomic.Bool
+	errGroup.Go(func() error {
+		defer coordinates.Close()
+		if err := locationService.ImportLocation(ctx, coordinates); err != nil {
+			return fmt.Errorf("could not import coordinates: %w", err)
+		}
+		imported.Store(true)
+		return nil
+	})
+
+	// readiness checks of /readyz and the grpc health service
+	readinessTimeout := time.Duration(config.GetHealthReadinessTimeout()) * time.Second
+	readinessChecks := map[string]httpfiber.HealthCheck{
+		"database": locationRepo.Ping,
+		"import": func(ctx context.Context) error {
+			if !imported.Load() {
+				return errors.New("initial coordinates are not imported")
+			}
+			return nil
+		},
+	}
+
 	// create handlers
 	httpHandler := httphandler.NewHandler(
 		httphandler.ServerConfig{
 			WriteTimeout: time.Duration(config.GetHttpWriteTimeout()) * time.Second,
 			ReadTimeout:  time.Duration(config.GetHttpReadTimeout()) * time.Second,
 			IdleTimeout:  time.Duration(config.GetHttpIdleTimeout()) * time.Second,
+			TLSConfig:    tlsConfig,
+		},
+		httphandler.StreamConfig{
+			PingInterval:   time.Duration(config.GetStreamPingInterval()) * time.Second,
+			PongTimeout:    time.Duration(config.GetStreamPongTimeout()) * time.Second,
+			MaxMessageSize: int64(config.GetStreamMaxMessageSize()),
+		},
+		httphandler.HealthConfig{
+			ReadinessTimeout: readinessTimeout,
+			ReadinessChecks:  readinessChecks,
 		},
 		appLogger,
 		acccessLogger,
 		locationService,
+		authService,
+		batcher,
+		rateLimiter,
 		config.GetAPIVersion(),
 	)
 
-	// import initial coordinates
-	file, err := os.Open(*coordinatesFile)
-	if err != nil {
-		appLogger.Error("could not open file", zap.String("file", *coordinatesFile), zap.Error(err))
-		return nil, err
-	}
-	defer file.Close()
+	grpcHandler := grpchandler.NewHandler(
+		grpchandler.HealthConfig{
+			CheckInterval:    time.Duration(config.GetHealthCheckInterval()) * time.Second,
+			ReadinessTimeout: readinessTimeout,
+			ReadinessChecks:  readinessChecks,
+		},
+		appLogger,
+		acccessLogger,
+		locationService,
+		authService,
+		tlsConfig,
+	)
 
-	if err := locationService.ImportLocation(ctx, file); err != nil {
-		appLogger.Error("could not import coordinates", zap.Error(err))
-		return nil, err
-	}
+	return httpHandler, grpcHandler, batcher, closeDB, nil
+}
+
+// NewLocationRepository creates the location repository of the configured database driver, the returned function
+// closes its database client
+func NewLocationRepository(ctx context.Context) (repositories.LocationRepository, func(), error) {
+	locationTTL := time.Duration(config.GetDBLocationTTL()) * time.Second
+	historyRetention := time.Duration(config.GetDBHistoryRetention()) * time.Second
 
-	return httpHandler, nil
+	switch config.GetDBDriver() {
+	case "memory":
+		locationRepo := memory.NewLocationRepository(locationTTL, historyRetention)
+		go locationRepo.Expire(ctx, time.Minute)
+		return locationRepo, func() {}, nil
+	case "postgres":
+		// initialize postgres pool and migrate schema
+		pool, err := postgres.NewPool(ctx, config.GetDBConnectionString())
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := postgres.Migrate(ctx, pool); err != nil {
+			pool.Close()
+			return nil, nil, err
+		}
+		locationRepo := postgres.NewLocationRepository(pool, locationTTL, historyRetention)
+		go locationRepo.Expire(ctx, time.Minute, func(err error) {
+			log.Error("could not expire locations", zap.Error(err))
+		})
+		return locationRepo, pool.Close, nil
+	case "mongo", "":
+		// initialize mongo db client
+		mongoClient, err := mongodb.NewMongoClient(config.GetDBConnectionString())
+		if err != nil {
+			return nil, nil, err
+		}
+		mongoDB, err := mongodb.CreateDatabase(
+			ctx,
+			mongoClient,
+			config.GetDBName(),
+			locationTTL,
+			historyRetention,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		closeDB := func() {
+			disconnectCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := mongoClient.Disconnect(disconnectCtx); err != nil {
+				log.Error("could not disconnect from mongodb", zap.Error(err))
+			}
+		}
+		return repositories.NewLocationRepository(mongoDB), closeDB, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown db driver: %s", config.GetDBDriver())
+	}
 }
 
 func ListenOsSignal(onSignal func()) {
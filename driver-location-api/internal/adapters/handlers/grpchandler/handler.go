@@ -0,0 +1,198 @@
+package grpchandler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/services"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
+	driverlocationv1 "github.com/aniladanir/bitaksi-casestudy/shared/proto/driverlocation/v1"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type Handler struct {
+	server    *grpc.Server
+	health    *health.Server
+	healthCfg HealthConfig
+	logger    *zap.Logger
+}
+
+// HealthConfig configures the serving status of the grpc health service
+type HealthConfig struct {
+	// CheckInterval is the interval ReadinessChecks are run in, 5 seconds by default
+	CheckInterval time.Duration
+	// ReadinessTimeout bounds the time all readiness checks may take
+	ReadinessTimeout time.Duration
+	// ReadinessChecks must all pass for the service to be serving
+	ReadinessChecks map[string]httpfiber.HealthCheck
+}
+
+// NewHandler creates the grpc handler, tlsConfig enables tls and client certificate authentication if it is set.
+// The health service is not serving until RunHealthChecks finds the readiness checks passing
+func NewHandler(healthCfg HealthConfig, logger *zap.Logger, accessLogger *zap.Logger, locationService services.LocationService, authService services.AuthService, tlsConfig *tls.Config) *Handler {
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			accessLogInterceptor(accessLogger),
+			authInterceptor(logger.With(zap.String("interceptor", "auth")), authService, domain.ScopeService),
+		),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	if healthCfg.CheckInterval <= 0 {
+		healthCfg.CheckInterval = 5 * time.Second
+	}
+	h := &Handler{
+		server:    grpc.NewServer(opts...),
+		health:    health.NewServer(),
+		healthCfg: healthCfg,
+		logger:    logger,
+	}
+	h.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(h.server, h.health)
+	driverlocationv1.RegisterDriverLocationServiceServer(
+		h.server,
+		newLocationServer(logger.With(zap.String("handler", "location")), locationService),
+	)
+	return h
+}
+
+func (h *Handler) Listen(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		h.logger.Error("listen on address failed", zap.Error(err), zap.String("address", address))
+		return fmt.Errorf("could not listen on %s: %w", address, err)
+	}
+	err = h.server.Serve(listener)
+	h.logger.Error("serve on address failed", zap.Error(err), zap.String("address", address))
+	return err
+}
+
+// Shutdown waits for the running calls to finish
+func (h *Handler) Shutdown() {
+	h.server.GracefulStop()
+}
+
+// RunHealthChecks sets the serving status from the readiness checks every check interval until ctx is done,
+// the service is not serving once ctx is done so that no calls are routed to it while it shuts down
+func (h *Handler) RunHealthChecks(ctx context.Context) error {
+	ticker := time.NewTicker(h.healthCfg.CheckInterval)
+	defer ticker.Stop()
+	for {
+		h.checkHealth(ctx)
+
+		select {
+		case <-ctx.Done():
+			h.health.Shutdown()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkHealth runs the readiness checks that /readyz runs, the service is serving if all of them pass
+func (h *Handler) checkHealth(ctx context.Context) {
+	failed := httpfiber.RunHealthChecks(ctx, h.healthCfg.ReadinessTimeout, h.healthCfg.ReadinessChecks)
+	if ctx.Err() != nil {
+		return
+	}
+	for name, err := range failed {
+		h.logger.Warn("readiness check failed", zap.String("check", name), zap.Error(err))
+	}
+	if len(failed) > 0 {
+		h.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+		return
+	}
+	h.setServingStatus(healthpb.HealthCheckResponse_SERVING)
+}
+
+// setServingStatus sets the status of the server and of the location service
+func (h *Handler) setServingStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	h.health.SetServingStatus("", status)
+	h.health.SetServingStatus(driverlocationv1.DriverLocationService_ServiceDesc.ServiceName, status)
+}
+
+// accessLogInterceptor logs incoming grpc calls
+func accessLogInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		logger.Info("Request",
+			zap.String("method", info.FullMethod),
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("latency", time.Since(start)),
+			zap.Bool("success", err == nil),
+		)
+
+		return resp, err
+	}
+}
+
+// authInterceptor accepts a verified client certificate or a bearer token in the authorization metadata,
+// the caller must have at least one of the scopes. Health checks are not authenticated so that probes reach them
+func authInterceptor(logger *zap.Logger, authService services.AuthService, scopes ...string) grpc.UnaryServerInterceptor {
+	healthPrefix := "/" + healthpb.Health_ServiceDesc.ServiceName + "/"
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, healthPrefix) {
+			return handler(ctx, req)
+		}
+
+		var principal *domain.Principal
+		var err error
+		if cert := peerCertificate(ctx); cert != nil {
+			principal, err = authService.AuthenticateCertificate(ctx, cert)
+		} else if tokens := metadata.ValueFromIncomingContext(ctx, "authorization"); len(tokens) > 0 {
+			principal, err = authService.Authenticate(ctx, tokens[0])
+		} else {
+			logger.Error("missing credentials", zap.String("method", info.FullMethod))
+			return nil, status.Error(codes.Unauthenticated, "missing credentials")
+		}
+		if err != nil {
+			logger.Error("authentication failed", zap.Error(err), zap.String("method", info.FullMethod))
+			if errs.IsUnauthorizedErr(err) {
+				return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+			}
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		if len(scopes) > 0 && !principal.HasAnyScope(scopes...) {
+			logger.Error("caller does not have required scopes", zap.String("subject", principal.Subject), zap.String("method", info.FullMethod))
+			return nil, status.Error(codes.PermissionDenied, "missing required scope")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// peerCertificate returns the verified client certificate of the call if there is one
+func peerCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return nil
+	}
+	return tlsInfo.State.VerifiedChains[0][0]
+}
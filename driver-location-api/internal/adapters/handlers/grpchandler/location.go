@@ -0,0 +1,221 @@
+package grpchandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/services"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
+	driverlocationv1 "github.com/aniladanir/bitaksi-casestudy/shared/proto/driverlocation/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultNearestDriversLimit = 10
+	maxNearestDriversLimit     = 100
+)
+
+var (
+	statusFromProto = map[driverlocationv1.DriverStatus]domain.DriverStatus{
+		driverlocationv1.DriverStatus_DRIVER_STATUS_AVAILABLE: domain.DriverStatusAvailable,
+		driverlocationv1.DriverStatus_DRIVER_STATUS_ON_TRIP:   domain.DriverStatusOnTrip,
+		driverlocationv1.DriverStatus_DRIVER_STATUS_OFFLINE:   domain.DriverStatusOffline,
+		driverlocationv1.DriverStatus_DRIVER_STATUS_BREAK:     domain.DriverStatusBreak,
+	}
+	statusToProto = map[domain.DriverStatus]driverlocationv1.DriverStatus{
+		domain.DriverStatusAvailable: driverlocationv1.DriverStatus_DRIVER_STATUS_AVAILABLE,
+		domain.DriverStatusOnTrip:    driverlocationv1.DriverStatus_DRIVER_STATUS_ON_TRIP,
+		domain.DriverStatusOffline:   driverlocationv1.DriverStatus_DRIVER_STATUS_OFFLINE,
+		domain.DriverStatusBreak:     driverlocationv1.DriverStatus_DRIVER_STATUS_BREAK,
+	}
+)
+
+type locationServer struct {
+	driverlocationv1.UnimplementedDriverLocationServiceServer
+	logger          *zap.Logger
+	locationService services.LocationService
+}
+
+func newLocationServer(logger *zap.Logger, locationService services.LocationService) *locationServer {
+	return &locationServer{
+		logger:          logger,
+		locationService: locationService,
+	}
+}
+
+func (ls *locationServer) FindNearestDriver(ctx context.Context, req *driverlocationv1.FindNearestDriverRequest) (*driverlocationv1.FindNearestDriverResponse, error) {
+	// parse request
+	point, err := pointFromProto(req.GetPoint())
+	if err != nil {
+		ls.logger.Error("invalid point", zap.Error(err))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	filter, err := filterFromProto(req.GetFilter())
+	if err != nil {
+		ls.logger.Error("invalid driver filter", zap.Error(err))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// call location service
+	driverLocation, distance, err := ls.locationService.FindNearestDriverDistance(
+		ctx,
+		domain.DriverLocation{Point: point},
+		req.GetRadius(),
+		filter,
+	)
+	if err != nil {
+		ls.logger.Error("could not find driver location", zap.Error(err))
+		return nil, toStatusError(err)
+	}
+
+	return &driverlocationv1.FindNearestDriverResponse{
+		Driver: driverDistanceToProto(domain.DriverDistance{
+			Distance:       *distance,
+			DriverLocation: *driverLocation,
+		}),
+	}, nil
+}
+
+func (ls *locationServer) FindNearestDrivers(ctx context.Context, req *driverlocationv1.FindNearestDriversRequest) (*driverlocationv1.FindNearestDriversResponse, error) {
+	// parse request
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = defaultNearestDriversLimit
+	}
+	if limit < 1 || limit > maxNearestDriversLimit {
+		ls.logger.Error("invalid limit", zap.Int("limit", limit))
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d", maxNearestDriversLimit)
+	}
+	point, err := pointFromProto(req.GetPoint())
+	if err != nil {
+		ls.logger.Error("invalid point", zap.Error(err))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	filter, err := filterFromProto(req.GetFilter())
+	if err != nil {
+		ls.logger.Error("invalid driver filter", zap.Error(err))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// call location service
+	driverDistances, err := ls.locationService.FindNearestDriverDistances(
+		ctx,
+		domain.DriverLocation{Point: point},
+		req.GetRadius(),
+		limit,
+		filter,
+	)
+	if err != nil {
+		ls.logger.Error("could not find driver locations", zap.Error(err))
+		return nil, toStatusError(err)
+	}
+
+	drivers := make([]*driverlocationv1.DriverDistance, 0, len(driverDistances))
+	for _, d := range driverDistances {
+		drivers = append(drivers, driverDistanceToProto(d))
+	}
+	return &driverlocationv1.FindNearestDriversResponse{
+		Drivers: drivers,
+	}, nil
+}
+
+func (ls *locationServer) UpsertDriverLocations(ctx context.Context, req *driverlocationv1.UpsertDriverLocationsRequest) (*driverlocationv1.UpsertDriverLocationsResponse, error) {
+	// parse and validate locations
+	locations := make([]domain.DriverLocation, 0, len(req.GetLocations()))
+	for i, l := range req.GetLocations() {
+		if err := ls.locationService.IsValidID(l.GetId()); err != nil {
+			ls.logger.Error("invalid location id", zap.Error(err), zap.Int("element", i+1))
+			return nil, status.Errorf(codes.InvalidArgument, "invalid id on element %d", i+1)
+		}
+		point, err := pointFromProto(l.GetPoint())
+		if err != nil {
+			ls.logger.Error("invalid point", zap.Error(err), zap.Int("element", i+1))
+			return nil, status.Errorf(codes.InvalidArgument, "invalid point on element %d", i+1)
+		}
+		location := domain.DriverLocation{
+			ID:    l.GetId(),
+			Point: point,
+		}
+		if l.GetStatus() != driverlocationv1.DriverStatus_DRIVER_STATUS_UNSPECIFIED {
+			s, ok := statusFromProto[l.GetStatus()]
+			if !ok {
+				ls.logger.Error("invalid driver status", zap.Int("element", i+1))
+				return nil, status.Errorf(codes.InvalidArgument, "invalid status on element %d", i+1)
+			}
+			location.Status = s
+		}
+		locations = append(locations, location)
+	}
+
+	if err := ls.locationService.CreateOrUpdateDriverLocations(ctx, locations); err != nil {
+		ls.logger.Error("could not upsert driver locations", zap.Error(err))
+		return nil, toStatusError(err)
+	}
+
+	return &driverlocationv1.UpsertDriverLocationsResponse{}, nil
+}
+
+// toStatusError maps service errors to grpc status errors
+func toStatusError(err error) error {
+	if errs.IsEntityNotFoundErr(err) {
+		return status.Error(codes.NotFound, "driver location not found")
+	}
+	return status.Error(codes.Internal, "internal error")
+}
+
+func pointFromProto(p *driverlocationv1.Point) (geojson.Point, error) {
+	if p == nil {
+		return geojson.Point{}, errors.New("missing point")
+	}
+	if p.GetLongitude() < -180 || p.GetLongitude() > 180 || p.GetLatitude() < -90 || p.GetLatitude() > 90 {
+		return geojson.Point{}, fmt.Errorf("coordinates out of range: %f, %f", p.GetLongitude(), p.GetLatitude())
+	}
+	return geojson.Point{
+		Type:        geojson.TypePoint,
+		Coordinates: geojson.Coordinate{p.GetLongitude(), p.GetLatitude()},
+	}, nil
+}
+
+func filterFromProto(f *driverlocationv1.DriverFilter) (domain.DriverFilter, error) {
+	var filter domain.DriverFilter
+	for _, s := range f.GetStatuses() {
+		status, ok := statusFromProto[s]
+		if !ok {
+			return filter, fmt.Errorf("invalid driver status: %s", s)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	if f.GetMaxAgeSeconds() < 0 {
+		return filter, fmt.Errorf("invalid max age: %d", f.GetMaxAgeSeconds())
+	}
+	filter.MaxAge = time.Duration(f.GetMaxAgeSeconds()) * time.Second
+	return filter, nil
+}
+
+func driverDistanceToProto(d domain.DriverDistance) *driverlocationv1.DriverDistance {
+	location := &driverlocationv1.DriverLocation{
+		Id: d.DriverLocation.ID,
+		Point: &driverlocationv1.Point{
+			Longitude: d.DriverLocation.Coordinates[0],
+			Latitude:  d.DriverLocation.Coordinates[1],
+		},
+		Status: statusToProto[d.DriverLocation.Status],
+	}
+	if d.DriverLocation.UpdatedAt != nil {
+		location.UpdatedAt = timestamppb.New(*d.DriverLocation.UpdatedAt)
+	}
+	return &driverlocationv1.DriverDistance{
+		Location: location,
+		Distance: &driverlocationv1.Distance{
+			Distance: d.Distance.Distance,
+			Unit:     d.Distance.Unit,
+		},
+	}
+}
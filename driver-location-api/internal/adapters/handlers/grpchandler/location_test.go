@@ -0,0 +1,286 @@
+package grpchandler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/services"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
+	"github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
+	driverlocationv1 "github.com/aniladanir/bitaksi-casestudy/shared/proto/driverlocation/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// MockLocationService implements the methods used by the grpc handler
+type MockLocationService struct {
+	services.LocationService
+	NotFound  bool
+	Filter    domain.DriverFilter
+	Locations []domain.DriverLocation
+}
+
+func (mls *MockLocationService) FindNearestDriverDistances(ctx context.Context, location domain.DriverLocation, searchRadius float64, limit int, filter domain.DriverFilter) ([]domain.DriverDistance, error) {
+	if mls.NotFound {
+		return nil, errs.ErrEntityNotFound("not found")
+	}
+	mls.Filter = filter
+	driverDistances := make([]domain.DriverDistance, 0, limit)
+	for i := 0; i < limit; i++ {
+		driverDistances = append(driverDistances, domain.DriverDistance{
+			Distance: domain.Distance{
+				Distance: float64(i + 1),
+				Unit:     "km",
+			},
+			DriverLocation: domain.DriverLocation{
+				ID:     "id",
+				Status: domain.DriverStatusAvailable,
+				Point: geojson.Point{
+					Type:        geojson.TypePoint,
+					Coordinates: geojson.Coordinate{29.0, 41.0},
+				},
+			},
+		})
+	}
+	return driverDistances, nil
+}
+
+func (mls *MockLocationService) CreateOrUpdateDriverLocations(ctx context.Context, locations []domain.DriverLocation) error {
+	mls.Locations = locations
+	return nil
+}
+
+func (*MockLocationService) IsValidID(id string) error {
+	if id == "" {
+		return errors.New("empty id")
+	}
+	return nil
+}
+
+// MockAuthService maps tokens to the scopes of the caller
+type MockAuthService struct {
+	services.AuthService
+}
+
+func (*MockAuthService) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	switch token {
+	case "Bearer service-token":
+		return &domain.Principal{Subject: "matching-api", Scopes: []string{domain.ScopeService}}, nil
+	case "Bearer rider-token":
+		return &domain.Principal{Subject: "rider", Scopes: []string{"rider"}}, nil
+	default:
+		return nil, errs.ErrUnauthorized(errors.New("invalid token"))
+	}
+}
+
+func newTestClient(t *testing.T, locationService services.LocationService) driverlocationv1.DriverLocationServiceClient {
+	return newTestClientWithToken(t, locationService, "service-token")
+}
+
+func newTestClientWithToken(t *testing.T, locationService services.LocationService, token string) driverlocationv1.DriverLocationServiceClient {
+	t.Helper()
+
+	handler := NewHandler(HealthConfig{}, zap.L(), zap.L(), locationService, &MockAuthService{}, nil)
+	return driverlocationv1.NewDriverLocationServiceClient(dialTestHandler(t, handler, token))
+}
+
+// dialTestHandler serves handler over an in memory listener, token is sent with every unary call if it is set
+func dialTestHandler(t *testing.T, handler *Handler, token string) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	go handler.server.Serve(listener)
+	t.Cleanup(handler.Shutdown)
+
+	opts := []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	if token != "" {
+		opts = append(opts, grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), method, req, reply, cc, opts...)
+		}))
+	}
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	if err != nil {
+		t.Fatalf("could not create client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestFindNearestDrivers(t *testing.T) {
+	point := &driverlocationv1.Point{Longitude: 29.0, Latitude: 41.0}
+
+	testCases := []struct {
+		name            string
+		request         *driverlocationv1.FindNearestDriversRequest
+		locationService *MockLocationService
+		expectedCode    codes.Code
+		expectedCount   int
+	}{
+		{
+			name:            "should success with default limit",
+			request:         &driverlocationv1.FindNearestDriversRequest{Point: point, Radius: 1000},
+			locationService: &MockLocationService{},
+			expectedCode:    codes.OK,
+			expectedCount:   defaultNearestDriversLimit,
+		},
+		{
+			name: "should success with status filter",
+			request: &driverlocationv1.FindNearestDriversRequest{
+				Point:  point,
+				Radius: 1000,
+				Limit:  3,
+				Filter: &driverlocationv1.DriverFilter{
+					Statuses: []driverlocationv1.DriverStatus{driverlocationv1.DriverStatus_DRIVER_STATUS_ON_TRIP},
+				},
+			},
+			locationService: &MockLocationService{},
+			expectedCode:    codes.OK,
+			expectedCount:   3,
+		},
+		{
+			name:            "should fail due to missing point",
+			request:         &driverlocationv1.FindNearestDriversRequest{Radius: 1000},
+			locationService: &MockLocationService{},
+			expectedCode:    codes.InvalidArgument,
+		},
+		{
+			name:            "should fail due to limit above max",
+			request:         &driverlocationv1.FindNearestDriversRequest{Point: point, Radius: 1000, Limit: maxNearestDriversLimit + 1},
+			locationService: &MockLocationService{},
+			expectedCode:    codes.InvalidArgument,
+		},
+		{
+			name:            "should fail due to not found",
+			request:         &driverlocationv1.FindNearestDriversRequest{Point: point, Radius: 1000},
+			locationService: &MockLocationService{NotFound: true},
+			expectedCode:    codes.NotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClient(t, tc.locationService)
+
+			resp, err := client.FindNearestDrivers(context.Background(), tc.request)
+			if status.Code(err) != tc.expectedCode {
+				t.Fatalf("expected code: %s, got: %v", tc.expectedCode, err)
+			}
+			if len(resp.GetDrivers()) != tc.expectedCount {
+				t.Errorf("expected %d drivers, got: %d", tc.expectedCount, len(resp.GetDrivers()))
+			}
+			for _, s := range tc.request.GetFilter().GetStatuses() {
+				if len(tc.locationService.Filter.Statuses) != 1 || statusToProto[tc.locationService.Filter.Statuses[0]] != s {
+					t.Errorf("expected status filter %s, got: %v", s, tc.locationService.Filter.Statuses)
+				}
+			}
+		})
+	}
+}
+
+func TestUpsertDriverLocations(t *testing.T) {
+	locationService := &MockLocationService{}
+	client := newTestClient(t, locationService)
+
+	_, err := client.UpsertDriverLocations(context.Background(), &driverlocationv1.UpsertDriverLocationsRequest{
+		Locations: []*driverlocationv1.DriverLocation{
+			{Id: "1", Point: &driverlocationv1.Point{Longitude: 29.0, Latitude: 41.0}, Status: driverlocationv1.DriverStatus_DRIVER_STATUS_BREAK},
+			{Id: "2", Point: &driverlocationv1.Point{Longitude: 29.1, Latitude: 41.1}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(locationService.Locations) != 2 {
+		t.Fatalf("expected 2 upserted locations, got: %d", len(locationService.Locations))
+	}
+	if locationService.Locations[0].Status != domain.DriverStatusBreak || locationService.Locations[1].Status != "" {
+		t.Errorf("unexpected statuses: %s, %s", locationService.Locations[0].Status, locationService.Locations[1].Status)
+	}
+
+	_, err = client.UpsertDriverLocations(context.Background(), &driverlocationv1.UpsertDriverLocationsRequest{
+		Locations: []*driverlocationv1.DriverLocation{
+			{Point: &driverlocationv1.Point{Longitude: 29.0, Latitude: 41.0}},
+		},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected invalid argument for missing id, got: %v", err)
+	}
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	request := &driverlocationv1.FindNearestDriversRequest{
+		Point:  &driverlocationv1.Point{Longitude: 29.0, Latitude: 41.0},
+		Radius: 1000,
+	}
+
+	testCases := []struct {
+		name         string
+		token        string
+		expectedCode codes.Code
+	}{
+		{name: "should success with service token", token: "service-token", expectedCode: codes.OK},
+		{name: "should fail without credentials", expectedCode: codes.Unauthenticated},
+		{name: "should fail with invalid token", token: "forged-token", expectedCode: codes.Unauthenticated},
+		{name: "should fail without service scope", token: "rider-token", expectedCode: codes.PermissionDenied},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClientWithToken(t, &MockLocationService{}, tc.token)
+
+			_, err := client.FindNearestDrivers(context.Background(), request)
+			if status.Code(err) != tc.expectedCode {
+				t.Errorf("expected code: %s, got: %v", tc.expectedCode, err)
+			}
+		})
+	}
+}
+
+func TestHealth(t *testing.T) {
+	var dbErr error
+	handler := NewHandler(HealthConfig{
+		ReadinessTimeout: time.Second,
+		ReadinessChecks: map[string]httpfiber.HealthCheck{
+			"database": func(ctx context.Context) error { return dbErr },
+		},
+	}, zap.L(), zap.L(), &MockLocationService{}, &MockAuthService{}, nil)
+	// probes send no credentials
+	client := healthpb.NewHealthClient(dialTestHandler(t, handler, ""))
+
+	expectStatus := func(expected healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("expected health check without credentials, got: %v", err)
+		}
+		if resp.GetStatus() != expected {
+			t.Errorf("expected status: %s, got: %s", expected, resp.GetStatus())
+		}
+	}
+
+	// not serving until the readiness checks pass
+	expectStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	handler.checkHealth(context.Background())
+	expectStatus(healthpb.HealthCheckResponse_SERVING)
+
+	dbErr = errors.New("database is unreachable")
+	handler.checkHealth(context.Background())
+	expectStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+}
@@ -0,0 +1,67 @@
+package httphandler
+
+import (
+	"net/http"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/services"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
+	"github.com/aniladanir/bitaksi-casestudy/shared/response"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+const ctxKeyPrincipal = "principal"
+
+type authHandler struct {
+	logger      *zap.Logger
+	authService services.AuthService
+}
+
+func newAuthHandler(logger *zap.Logger, authService services.AuthService) *authHandler {
+	return &authHandler{
+		logger:      logger,
+		authService: authService,
+	}
+}
+
+// Authenticate accepts a verified client certificate or a bearer token, the caller must have at least one of the scopes
+func (ah *authHandler) Authenticate(scopes ...string) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		logger := httpfiber.CtxLogger(ctx, ah.logger)
+
+		var principal *domain.Principal
+		var err error
+		if tlsState := ctx.Context().TLSConnectionState(); tlsState != nil && len(tlsState.VerifiedChains) > 0 {
+			principal, err = ah.authService.AuthenticateCertificate(ctx.UserContext(), tlsState.VerifiedChains[0][0])
+		} else if tokenStr := ctx.Get(fiber.HeaderAuthorization); tokenStr != "" {
+			principal, err = ah.authService.Authenticate(ctx.UserContext(), tokenStr)
+		} else {
+			logger.Error("missing credentials")
+			return response.Fail(ctx, response.ErrCodeUnauthorized, response.ErrMsgUnauthorized, http.StatusUnauthorized)
+		}
+		if err != nil {
+			logger.Error("authentication failed", zap.Error(err))
+			if errs.IsUnauthorizedErr(err) {
+				return response.Fail(ctx, response.ErrCodeUnauthorized, response.ErrMsgUnauthorized, http.StatusUnauthorized)
+			}
+			return response.Fail(ctx, response.ErrCodeInternal, response.ErrMsgInternal, http.StatusInternalServerError)
+		}
+
+		if len(scopes) > 0 && !principal.HasAnyScope(scopes...) {
+			logger.Error("caller does not have required scopes", zap.String("subject", principal.Subject), zap.Strings("required", scopes))
+			return response.Fail(ctx, response.ErrCodeForbidden, response.ErrMsgForbidden, http.StatusForbidden)
+		}
+
+		ctx.Locals(ctxKeyPrincipal, principal)
+		ctx.Locals(httpfiber.CtxKeySubject, principal.Subject)
+		return ctx.Next()
+	}
+}
+
+// principalFromCtx returns the principal stored by the Authenticate middleware, nil if the route is not authenticated
+func principalFromCtx(ctx fiber.Ctx) *domain.Principal {
+	principal, _ := ctx.Locals(ctxKeyPrincipal).(*domain.Principal)
+	return principal
+}
@@ -0,0 +1,143 @@
+package httphandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/services"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/response"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// MockAuthService maps tokens to the scopes of the caller
+type MockAuthService struct {
+	services.AuthService
+}
+
+func (*MockAuthService) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	switch token {
+	case "Bearer service-token":
+		return &domain.Principal{Subject: "matching-api", Scopes: []string{domain.ScopeService}}, nil
+	case "Bearer driver-token":
+		return &domain.Principal{Subject: "driver", Scopes: []string{domain.ScopeDriver}, DriverID: "driver-1"}, nil
+	case "Bearer rider-token":
+		return &domain.Principal{Subject: "rider", Scopes: []string{"rider"}}, nil
+	default:
+		return nil, errs.ErrUnauthorized(errors.New("invalid token"))
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	authHandler := newAuthHandler(zap.L(), &MockAuthService{})
+
+	app := fiber.New()
+	app.Use(authHandler.Authenticate(domain.ScopeService))
+	app.Get("/protected", func(ctx fiber.Ctx) error {
+		return ctx.SendStatus(http.StatusOK)
+	})
+	startTestServer(t, app)
+	defer app.Shutdown()
+
+	testCases := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{name: "should success with service token", token: "Bearer service-token", expectedStatus: http.StatusOK},
+		{name: "should fail without token", expectedStatus: http.StatusUnauthorized},
+		{name: "should fail with invalid token", token: "Bearer forged-token", expectedStatus: http.StatusUnauthorized},
+		{name: "should fail without service scope", token: "Bearer rider-token", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/protected", nil)
+			if tc.token != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tc.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected status code: %d, got: %d", tc.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestAddLocationsAuthorization(t *testing.T) {
+	authHandler := newAuthHandler(zap.L(), &MockAuthService{})
+	locationHandler := newLocationHandler(zap.L(), &MockLocationService{Valid: true})
+
+	app := fiber.New()
+	app.Put("/location", locationHandler.AddLocations, authHandler.Authenticate(domain.ScopeService, domain.ScopeDriver))
+	startTestServer(t, app)
+	defer app.Shutdown()
+
+	location := func(id string) map[string]any {
+		return map[string]any{"id": id, "type": "Point", "coordinates": []float64{29.0, 41.0}}
+	}
+
+	testCases := []struct {
+		name           string
+		token          string
+		payload        []map[string]any
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "should success for own location",
+			token:          "Bearer driver-token",
+			payload:        []map[string]any{location("driver-1")},
+			expectedStatus: http.StatusOK,
+			expectedCode:   response.SuccessCode,
+		},
+		{
+			name:           "should fail for other driver",
+			token:          "Bearer driver-token",
+			payload:        []map[string]any{location("driver-1"), location("driver-2")},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   response.ErrCodeDriverIDMismatch,
+		},
+		{
+			name:           "should success for many drivers with service token",
+			token:          "Bearer service-token",
+			payload:        []map[string]any{location("driver-1"), location("driver-2")},
+			expectedStatus: http.StatusOK,
+			expectedCode:   response.SuccessCode,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			payloadBytes, _ := json.Marshal(tc.payload)
+			req, _ := http.NewRequest(http.MethodPut, "http://localhost:8080/location", bytes.NewBuffer(payloadBytes))
+			req.Header.Set(fiber.HeaderAuthorization, tc.token)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var body response.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected status code: %d, got: %d", tc.expectedStatus, resp.StatusCode)
+			}
+			if body.Code != tc.expectedCode {
+				t.Errorf("expected code: %s, got: %s", tc.expectedCode, body.Code)
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package httphandler
 
 import (
+	"crypto/tls"
 	"time"
 
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/services"
+	"github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
 	"github.com/gofiber/fiber/v3"
 	"go.uber.org/zap"
 )
@@ -12,16 +14,30 @@ type Handler struct {
 	app             *fiber.App
 	apiVersion      string
 	logger          *zap.Logger
+	tlsConfig       *tls.Config
 	locationHandler *locationHandler
+	streamHandler   *streamHandler
+	authHandler     *authHandler
+	rateLimiter     *httpfiber.RateLimiter
+	healthCfg       HealthConfig
 }
 
 type ServerConfig struct {
 	WriteTimeout time.Duration
 	ReadTimeout  time.Duration
 	IdleTimeout  time.Duration
+	// TLSConfig enables https and client certificate authentication if it is set
+	TLSConfig *tls.Config
 }
 
-func NewHandler(serverCfg ServerConfig, logger *zap.Logger, accessLogger *zap.Logger, locationService services.LocationService, apiVersion string) *Handler {
+type HealthConfig struct {
+	// ReadinessTimeout bounds the time all readiness checks may take
+	ReadinessTimeout time.Duration
+	// ReadinessChecks are run by name on every readiness probe
+	ReadinessChecks map[string]httpfiber.HealthCheck
+}
+
+func NewHandler(serverCfg ServerConfig, streamCfg StreamConfig, healthCfg HealthConfig, logger *zap.Logger, accessLogger *zap.Logger, locationService services.LocationService, authService services.AuthService, batcher *services.LocationBatcher, rateLimiter *httpfiber.RateLimiter, apiVersion string) *Handler {
 	h := &Handler{
 		app: fiber.New(fiber.Config{
 			ReadTimeout:  serverCfg.ReadTimeout,
@@ -29,7 +45,12 @@ func NewHandler(serverCfg ServerConfig, logger *zap.Logger, accessLogger *zap.Lo
 			IdleTimeout:  serverCfg.IdleTimeout,
 		}),
 		logger:          logger,
+		tlsConfig:       serverCfg.TLSConfig,
 		locationHandler: newLocationHandler(logger.With(zap.String("handler", "location")), locationService),
+		streamHandler:   newStreamHandler(logger.With(zap.String("handler", "stream")), locationService, batcher, streamCfg),
+		authHandler:     newAuthHandler(logger.With(zap.String("handler", "auth")), authService),
+		rateLimiter:     rateLimiter,
+		healthCfg:       healthCfg,
 		apiVersion:      apiVersion,
 	}
 	h.applyRoutes(accessLogger)
@@ -37,12 +58,24 @@ func NewHandler(serverCfg ServerConfig, logger *zap.Logger, accessLogger *zap.Lo
 }
 
 func (h *Handler) Listen(address string) error {
-	err := h.app.Listen(address)
+	if h.tlsConfig == nil {
+		err := h.app.Listen(address)
+		h.logger.Error("listen on address failed", zap.Error(err), zap.String("address", address))
+		return err
+	}
+
+	listener, err := tls.Listen("tcp", address, h.tlsConfig)
+	if err != nil {
+		h.logger.Error("listen on address failed", zap.Error(err), zap.String("address", address))
+		return err
+	}
+	err = h.app.Listener(listener)
 	h.logger.Error("listen on address failed", zap.Error(err), zap.String("address", address))
 	return err
 }
 
 func (h *Handler) Shutdown() error {
+	h.streamHandler.Close()
 	if err := h.app.Shutdown(); err != nil {
 		h.logger.Error("shutdown failed", zap.Error(err))
 		return err
@@ -2,8 +2,12 @@ package httphandler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/services"
@@ -15,6 +19,13 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	defaultNearestDriversLimit = 10
+	maxNearestDriversLimit     = 100
+	defaultWithinDriversLimit  = 50
+	maxWithinDriversLimit      = 500
+)
+
 type locationHandler struct {
 	logger          *zap.Logger
 	locationService services.LocationService
@@ -38,15 +49,26 @@ func (dh *locationHandler) AddLocations(ctx fiber.Ctx) error {
 		return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
 	}
 
-	// validate location ids
+	// validate location ids and statuses, drivers may only update their own location
+	principal := principalFromCtx(ctx)
 	for i := 0; i < len(payload); i++ {
 		if err := dh.locationService.IsValidID(payload[i].ID); err != nil {
 			logger.Error("invalid location id", zap.Error(err), zap.Int("element", i+1))
 			return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
 		}
+		if principal != nil && !principal.CanUpdateDriver(payload[i].ID) {
+			logger.Error("location id does not match authenticated driver", zap.String("subject", principal.Subject), zap.Int("element", i+1))
+			return response.Fail(ctx, response.ErrCodeDriverIDMismatch, response.ErrMsgDriverIDMismatch, http.StatusForbidden)
+		}
+		if payload[i].Status != "" {
+			if err := payload[i].Status.IsValid(); err != nil {
+				logger.Error("invalid driver status", zap.Error(err), zap.Int("element", i+1))
+				return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
+			}
+		}
 	}
 
-	if err := dh.locationService.CreateOrUpdateDriverLocations(ctx.Context(), payload); err != nil {
+	if err := dh.locationService.CreateOrUpdateDriverLocations(ctx.UserContext(), payload); err != nil {
 		logger.Error("invalid location id", zap.Error(err))
 		return response.Fail(ctx, response.ErrCodeInternal, response.ErrMsgInternal, http.StatusInternalServerError)
 	}
@@ -54,6 +76,43 @@ func (dh *locationHandler) AddLocations(ctx fiber.Ctx) error {
 	return response.Success(ctx, nil)
 }
 
+func (dh *locationHandler) UpdateStatus(ctx fiber.Ctx) error {
+	type RequestBody struct {
+		Status domain.DriverStatus `json:"status"`
+	}
+
+	// get context logger
+	logger := httpfiber.CtxLogger(ctx, dh.logger)
+
+	// validate driver id
+	id := ctx.Params("id")
+	if err := dh.locationService.IsValidID(id); err != nil {
+		logger.Error("invalid driver id", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeBadRequest, response.ErrMsgBadRequest, http.StatusBadRequest)
+	}
+
+	// parse payload
+	var payload RequestBody
+	if err := json.Unmarshal(ctx.Body(), &payload); err != nil {
+		logger.Error("could not unmarshal payload", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
+	}
+	if err := payload.Status.IsValid(); err != nil {
+		logger.Error("invalid driver status", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
+	}
+
+	if err := dh.locationService.UpdateDriverStatus(ctx.UserContext(), id, payload.Status); err != nil {
+		logger.Error("could not update driver status", zap.Error(err))
+		if errs.IsEntityNotFoundErr(err) {
+			return response.Fail(ctx, response.ErrCodeNotFound, response.ErrMsgNotFound, http.StatusNotFound)
+		}
+		return response.Fail(ctx, response.ErrCodeInternal, response.ErrMsgInternal, http.StatusInternalServerError)
+	}
+
+	return response.Success(ctx, nil)
+}
+
 func (dh *locationHandler) FindNearestDriver(ctx fiber.Ctx) error {
 	type ResponseBody struct {
 		Distance       domain.Distance       `json:"distance"`
@@ -70,6 +129,120 @@ func (dh *locationHandler) FindNearestDriver(ctx fiber.Ctx) error {
 		return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
 	}
 
+	filter, err := parseDriverFilter(ctx)
+	if err != nil {
+		logger.Error("invalid driver filter query params", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
+	}
+
+	// parse body
+	point, err := parsePoint(ctx.Body())
+	if err != nil {
+		logger.Error("invalid geojson payload", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
+	}
+
+	// call location service
+	driverLocation, distance, err := dh.locationService.FindNearestDriverDistance(
+		ctx.UserContext(),
+		domain.DriverLocation{Point: point},
+		radius,
+		filter,
+	)
+	if err != nil {
+		logger.Error("could not find driver location", zap.Error(err))
+		if errs.IsInternalErr(err) {
+			return response.Fail(ctx, response.ErrCodeInternal, response.ErrMsgInternal, http.StatusInternalServerError)
+		}
+		if errs.IsEntityNotFoundErr(err) {
+			return response.Fail(ctx, response.ErrCodeNotFound, response.ErrMsgNotFound, http.StatusNotFound)
+		}
+
+	}
+
+	return response.Success(ctx, &ResponseBody{
+		Distance:       *distance,
+		DriverLocation: *driverLocation,
+	})
+}
+
+func (dh *locationHandler) FindNearestDrivers(ctx fiber.Ctx) error {
+	// get context logger
+	logger := httpfiber.CtxLogger(ctx, dh.logger)
+
+	// parse query params
+	var radius float64
+	var err error
+	if radius, err = strconv.ParseFloat(ctx.Query("radius"), 64); err != nil {
+		logger.Error("invalid radius query param")
+		return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
+	}
+	limit := defaultNearestDriversLimit
+	if ctx.Query("limit") != "" {
+		if limit, err = strconv.Atoi(ctx.Query("limit")); err != nil || limit < 1 || limit > maxNearestDriversLimit {
+			logger.Error("invalid limit query param")
+			return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
+		}
+	}
+
+	filter, err := parseDriverFilter(ctx)
+	if err != nil {
+		logger.Error("invalid driver filter query params", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
+	}
+
+	// parse body
+	point, err := parsePoint(ctx.Body())
+	if err != nil {
+		logger.Error("invalid geojson payload", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
+	}
+
+	// call location service
+	driverDistances, err := dh.locationService.FindNearestDriverDistances(
+		ctx.UserContext(),
+		domain.DriverLocation{Point: point},
+		radius,
+		limit,
+		filter,
+	)
+	if err != nil {
+		logger.Error("could not find driver locations", zap.Error(err))
+		if errs.IsEntityNotFoundErr(err) {
+			return response.Fail(ctx, response.ErrCodeNotFound, response.ErrMsgNotFound, http.StatusNotFound)
+		}
+		return response.Fail(ctx, response.ErrCodeInternal, response.ErrMsgInternal, http.StatusInternalServerError)
+	}
+
+	return response.Success(ctx, driverDistances)
+}
+
+func (dh *locationHandler) FindDriversWithin(ctx fiber.Ctx) error {
+	// get context logger
+	logger := httpfiber.CtxLogger(ctx, dh.logger)
+
+	// parse query params
+	var err error
+	offset := 0
+	if ctx.Query("offset") != "" {
+		if offset, err = strconv.Atoi(ctx.Query("offset")); err != nil || offset < 0 {
+			logger.Error("invalid offset query param")
+			return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
+		}
+	}
+	limit := defaultWithinDriversLimit
+	if ctx.Query("limit") != "" {
+		if limit, err = strconv.Atoi(ctx.Query("limit")); err != nil || limit < 1 || limit > maxWithinDriversLimit {
+			logger.Error("invalid limit query param")
+			return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
+		}
+	}
+	filter, err := parseDriverFilter(ctx)
+	if err != nil {
+		logger.Error("invalid driver filter query params", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
+	}
+
 	// parse body
 	geo, err := geojson.UnmarshalJSON(ctx.Body())
 	if err != nil {
@@ -78,8 +251,8 @@ func (dh *locationHandler) FindNearestDriver(ctx fiber.Ctx) error {
 	}
 
 	// validate the type of geojson data
-	if geo.GetType() != geojson.TypePoint {
-		logger.Error("type of geojson data is not a point")
+	if geo.GetType() != geojson.TypePolygon && geo.GetType() != geojson.TypeMultiPolygon {
+		logger.Error("type of geojson data is not a polygon or multipolygon")
 		return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
 	}
 
@@ -90,24 +263,91 @@ func (dh *locationHandler) FindNearestDriver(ctx fiber.Ctx) error {
 	}
 
 	// call location service
-	driverLocation, distance, err := dh.locationService.FindNearestDriverDistance(
-		ctx.Context(),
-		domain.DriverLocation{Point: geo.(geojson.Point)},
-		radius,
-	)
+	page, err := dh.locationService.FindDriversWithin(ctx.UserContext(), geo, offset, limit, filter)
 	if err != nil {
-		logger.Error("could not find driver location", zap.Error(err))
-		if errs.IsInternalErr(err) {
-			return response.Fail(ctx, response.ErrCodeInternal, response.ErrMsgInternal, http.StatusInternalServerError)
+		logger.Error("could not find driver locations", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInternal, response.ErrMsgInternal, http.StatusInternalServerError)
+	}
+
+	return response.Success(ctx, page)
+}
+
+func (dh *locationHandler) GetDriverTrail(ctx fiber.Ctx) error {
+	// get context logger
+	logger := httpfiber.CtxLogger(ctx, dh.logger)
+
+	// validate driver id
+	id := ctx.Params("id")
+	if err := dh.locationService.IsValidID(id); err != nil {
+		logger.Error("invalid driver id", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeBadRequest, response.ErrMsgBadRequest, http.StatusBadRequest)
+	}
+
+	// parse query params
+	from, err := time.Parse(time.RFC3339, ctx.Query("from"))
+	if err != nil {
+		logger.Error("invalid from query param", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
+	}
+	to := time.Now()
+	if ctx.Query("to") != "" {
+		if to, err = time.Parse(time.RFC3339, ctx.Query("to")); err != nil {
+			logger.Error("invalid to query param", zap.Error(err))
+			return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
 		}
+	}
+	if !from.Before(to) {
+		logger.Error("from query param is not before to query param")
+		return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
+	}
+
+	// call location service
+	trail, err := dh.locationService.GetDriverTrail(ctx.UserContext(), id, from, to)
+	if err != nil {
+		logger.Error("could not get driver trail", zap.Error(err))
 		if errs.IsEntityNotFoundErr(err) {
 			return response.Fail(ctx, response.ErrCodeNotFound, response.ErrMsgNotFound, http.StatusNotFound)
 		}
+		return response.Fail(ctx, response.ErrCodeInternal, response.ErrMsgInternal, http.StatusInternalServerError)
+	}
 
+	return response.Success(ctx, trail)
+}
+
+// parsePoint parses body as geojson data and validates that it is a point
+func parsePoint(body []byte) (geojson.Point, error) {
+	geo, err := geojson.UnmarshalJSON(body)
+	if err != nil {
+		return geojson.Point{}, fmt.Errorf("could not unmarshal geojson data: %w", err)
+	}
+	if geo.GetType() != geojson.TypePoint {
+		return geojson.Point{}, errors.New("type of geojson data is not a point")
+	}
+	if !geo.IsValid() {
+		return geojson.Point{}, errors.New("invalid geojson data")
 	}
+	return geo.(geojson.Point), nil
+}
 
-	return response.Success(ctx, &ResponseBody{
-		Distance:       *distance,
-		DriverLocation: *driverLocation,
-	})
+// parseDriverFilter parses comma separated driver statuses in status query param
+// and the maximum location age in seconds in maxAge query param
+func parseDriverFilter(ctx fiber.Ctx) (domain.DriverFilter, error) {
+	var filter domain.DriverFilter
+	if ctx.Query("status") != "" {
+		for _, s := range strings.Split(ctx.Query("status"), ",") {
+			status := domain.DriverStatus(strings.TrimSpace(s))
+			if err := status.IsValid(); err != nil {
+				return filter, err
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	if ctx.Query("maxAge") != "" {
+		maxAge, err := strconv.Atoi(ctx.Query("maxAge"))
+		if err != nil || maxAge < 1 {
+			return filter, fmt.Errorf("invalid max age: %s", ctx.Query("maxAge"))
+		}
+		filter.MaxAge = time.Duration(maxAge) * time.Second
+	}
+	return filter, nil
 }
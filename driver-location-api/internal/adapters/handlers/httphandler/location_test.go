@@ -6,10 +6,14 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"net"
 	"net/http"
 	"reflect"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/adapters/repositories/memory"
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/services"
 	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
@@ -24,9 +28,11 @@ type MockLocationService struct {
 	NotFound       bool
 	DriverLocation domain.DriverLocation
 	Distance       domain.Distance
+	// Filter is the driver filter of the last nearest drivers search
+	Filter domain.DriverFilter
 }
 
-func (mls *MockLocationService) FindNearestDriverDistance(ctx context.Context, location domain.DriverLocation, searchRadius float64) (*domain.DriverLocation, *domain.Distance, error) {
+func (mls *MockLocationService) FindNearestDriverDistance(ctx context.Context, location domain.DriverLocation, searchRadius float64, filter domain.DriverFilter) (*domain.DriverLocation, *domain.Distance, error) {
 	if mls.NotFound {
 		return nil, nil, errs.ErrEntityNotFound("not found")
 	}
@@ -46,9 +52,45 @@ func (mls *MockLocationService) FindNearestDriverDistance(ctx context.Context, l
 		},
 		nil
 }
+func (mls *MockLocationService) FindNearestDriverDistances(ctx context.Context, location domain.DriverLocation, searchRadius float64, limit int, filter domain.DriverFilter) ([]domain.DriverDistance, error) {
+	mls.Filter = filter
+	if mls.NotFound {
+		return nil, errs.ErrEntityNotFound("not found")
+	}
+	driverDistances := make([]domain.DriverDistance, 0, limit)
+	for i := 0; i < limit; i++ {
+		driverDistances = append(driverDistances, domain.DriverDistance{
+			Distance: domain.Distance{
+				Distance: float64(10 * (i + 1)),
+				Unit:     "km",
+			},
+			DriverLocation: domain.DriverLocation{
+				ID: strconv.Itoa(i + 1),
+				Point: geojson.Point{
+					Type:        geojson.TypePoint,
+					Coordinates: geojson.Coordinate{10, 10},
+				},
+			},
+		})
+	}
+	return driverDistances, nil
+}
+func (*MockLocationService) UpdateDriverStatus(ctx context.Context, id string, status domain.DriverStatus) error {
+	return nil
+}
 func (*MockLocationService) CreateOrUpdateDriverLocations(ctx context.Context, locations []domain.DriverLocation) error {
 	return nil
 }
+func (*MockLocationService) FindDriversWithin(ctx context.Context, area geojson.Geometry, offset, limit int, filter domain.DriverFilter) (*domain.DriverLocationPage, error) {
+	return &domain.DriverLocationPage{
+		Locations: []domain.DriverLocation{},
+		Offset:    offset,
+		Limit:     limit,
+	}, nil
+}
+func (*MockLocationService) GetDriverTrail(ctx context.Context, id string, from, to time.Time) (*geojson.LineString, error) {
+	return nil, errs.ErrEntityNotFound("not found")
+}
 func (*MockLocationService) ImportLocation(ctx context.Context, reader io.Reader) error { return nil }
 func (mls *MockLocationService) IsValidID(id string) error {
 	if mls.Valid {
@@ -147,14 +189,9 @@ func TestFindNearestDriver(t *testing.T) {
 			locationHandler := newLocationHandler(zap.L(), tc.locationService)
 
 			app := fiber.New()
-			go func() {
-				if err := app.Listen("localhost:8080"); err != nil {
-					t.Errorf("could not start test server: %v", err)
-				}
-			}()
-			defer app.Shutdown()
-
 			app.Post("/location", locationHandler.FindNearestDriver)
+			startTestServer(t, app)
+			defer app.Shutdown()
 
 			payloadBytes, err := json.Marshal(tc.payload)
 			if err != nil {
@@ -185,3 +222,467 @@ func TestFindNearestDriver(t *testing.T) {
 		})
 	}
 }
+
+func TestFindNearestDrivers(t *testing.T) {
+	testCases := []struct {
+		name            string
+		payload         geojson.Point
+		query           string
+		locationService services.LocationService
+		expectedStatus  int
+		expectedCount   int
+		expectedCode    string
+		expectedMaxAge  time.Duration
+	}{
+		{
+			name: "should success with requested limit",
+			payload: geojson.Point{
+				Type:        geojson.TypePoint,
+				Coordinates: geojson.Coordinate{10, 10},
+			},
+			query:           "radius=1000&limit=3",
+			locationService: &MockLocationService{Valid: true},
+			expectedStatus:  http.StatusOK,
+			expectedCount:   3,
+			expectedCode:    response.SuccessCode,
+		},
+		{
+			name: "should success with default limit",
+			payload: geojson.Point{
+				Type:        geojson.TypePoint,
+				Coordinates: geojson.Coordinate{10, 10},
+			},
+			query:           "radius=1000",
+			locationService: &MockLocationService{Valid: true},
+			expectedStatus:  http.StatusOK,
+			expectedCount:   defaultNearestDriversLimit,
+			expectedCode:    response.SuccessCode,
+		},
+		{
+			name: "should fail due to invalid limit",
+			payload: geojson.Point{
+				Type:        geojson.TypePoint,
+				Coordinates: geojson.Coordinate{10, 10},
+			},
+			query:           "radius=1000&limit=0",
+			locationService: &MockLocationService{Valid: true},
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    response.ErrCodeInvalidQueryParam,
+		},
+		{
+			name: "should fail due to unknown driver status",
+			payload: geojson.Point{
+				Type:        geojson.TypePoint,
+				Coordinates: geojson.Coordinate{10, 10},
+			},
+			query:           "radius=1000&status=available,driving",
+			locationService: &MockLocationService{Valid: true},
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    response.ErrCodeInvalidQueryParam,
+		},
+		{
+			name: "should success with max age",
+			payload: geojson.Point{
+				Type:        geojson.TypePoint,
+				Coordinates: geojson.Coordinate{10, 10},
+			},
+			query:           "radius=1000&limit=3&maxAge=60",
+			locationService: &MockLocationService{Valid: true},
+			expectedStatus:  http.StatusOK,
+			expectedCount:   3,
+			expectedCode:    response.SuccessCode,
+			expectedMaxAge:  time.Minute,
+		},
+		{
+			name: "should fail due to negative max age",
+			payload: geojson.Point{
+				Type:        geojson.TypePoint,
+				Coordinates: geojson.Coordinate{10, 10},
+			},
+			query:           "radius=1000&maxAge=-60",
+			locationService: &MockLocationService{Valid: true},
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    response.ErrCodeInvalidQueryParam,
+		},
+		{
+			name: "should fail due to zero max age",
+			payload: geojson.Point{
+				Type:        geojson.TypePoint,
+				Coordinates: geojson.Coordinate{10, 10},
+			},
+			query:           "radius=1000&maxAge=0",
+			locationService: &MockLocationService{Valid: true},
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    response.ErrCodeInvalidQueryParam,
+		},
+		{
+			name: "should fail due to garbage max age",
+			payload: geojson.Point{
+				Type:        geojson.TypePoint,
+				Coordinates: geojson.Coordinate{10, 10},
+			},
+			query:           "radius=1000&maxAge=1m",
+			locationService: &MockLocationService{Valid: true},
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    response.ErrCodeInvalidQueryParam,
+		},
+		{
+			name: "should fail due to no location found",
+			payload: geojson.Point{
+				Type:        geojson.TypePoint,
+				Coordinates: geojson.Coordinate{10, 10},
+			},
+			query:           "radius=1000&limit=3",
+			locationService: &MockLocationService{Valid: true, NotFound: true},
+			expectedStatus:  http.StatusNotFound,
+			expectedCode:    response.ErrCodeNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			locationHandler := newLocationHandler(zap.L(), tc.locationService)
+
+			app := fiber.New()
+			app.Post("/locations/nearest", locationHandler.FindNearestDrivers)
+			startTestServer(t, app)
+			defer app.Shutdown()
+
+			payloadBytes, err := json.Marshal(tc.payload)
+			if err != nil {
+				t.Fatalf("could not marshal payload: %v", err)
+			}
+
+			resp, err := http.Post("http://localhost:8080/locations/nearest?"+tc.query, "application/json", bytes.NewBuffer(payloadBytes))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected status code: %d, got: %d", tc.expectedStatus, resp.StatusCode)
+			}
+
+			var body struct {
+				Code string                  `json:"code"`
+				Data []domain.DriverDistance `json:"data"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("cannot decode response body: %v", err)
+			}
+			if body.Code != tc.expectedCode {
+				t.Errorf("expected code: %s, got: %s", tc.expectedCode, body.Code)
+			}
+			if len(body.Data) != tc.expectedCount {
+				t.Errorf("expected %d drivers, got: %d", tc.expectedCount, len(body.Data))
+			}
+			if maxAge := tc.locationService.(*MockLocationService).Filter.MaxAge; maxAge != tc.expectedMaxAge {
+				t.Errorf("expected max age %s, got: %s", tc.expectedMaxAge, maxAge)
+			}
+		})
+	}
+}
+
+func TestFindDriversWithin(t *testing.T) {
+	testCases := []struct {
+		name           string
+		payload        string
+		query          string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "should success with polygon",
+			payload:        `{"type":"Polygon","coordinates":[[[28.9,41.0],[29.1,41.0],[29.1,41.2],[28.9,41.0]]]}`,
+			query:          "offset=10&limit=20",
+			expectedStatus: http.StatusOK,
+			expectedCode:   response.SuccessCode,
+		},
+		{
+			name:           "should success with multipolygon",
+			payload:        `{"type":"MultiPolygon","coordinates":[[[[28.9,41.0],[29.1,41.0],[29.1,41.2],[28.9,41.0]]]]}`,
+			expectedStatus: http.StatusOK,
+			expectedCode:   response.SuccessCode,
+		},
+		{
+			name:           "should fail due to point payload",
+			payload:        `{"type":"Point","coordinates":[28.9,41.0]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeInvalidPayload,
+		},
+		{
+			name:           "should fail due to unclosed polygon",
+			payload:        `{"type":"Polygon","coordinates":[[[28.9,41.0],[29.1,41.0],[29.1,41.2],[28.8,41.0]]]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeInvalidPayload,
+		},
+		{
+			name:           "should fail due to polygon position without two coordinates",
+			payload:        `{"type":"Polygon","coordinates":[[[1],[0,0],[0,0],[1]]]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeInvalidPayload,
+		},
+		{
+			name:           "should fail due to multipolygon position with three coordinates",
+			payload:        `{"type":"MultiPolygon","coordinates":[[[[28.9,41.0,1],[29.1,41.0],[29.1,41.2],[28.9,41.0,1]]]]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeInvalidPayload,
+		},
+		{
+			name:           "should fail due to negative offset",
+			payload:        `{"type":"Polygon","coordinates":[[[28.9,41.0],[29.1,41.0],[29.1,41.2],[28.9,41.0]]]}`,
+			query:          "offset=-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeInvalidQueryParam,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			locationHandler := newLocationHandler(zap.L(), &MockLocationService{Valid: true})
+
+			app := fiber.New()
+			app.Post("/locations/within", locationHandler.FindDriversWithin)
+			startTestServer(t, app)
+			defer app.Shutdown()
+
+			resp, err := http.Post("http://localhost:8080/locations/within?"+tc.query, "application/json", bytes.NewBufferString(tc.payload))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected status code: %d, got: %d", tc.expectedStatus, resp.StatusCode)
+			}
+
+			var body response.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("cannot decode response body: %v", err)
+			}
+			if body.Code != tc.expectedCode {
+				t.Errorf("expected code: %s, got: %s", tc.expectedCode, body.Code)
+			}
+		})
+	}
+}
+
+func TestUpdateStatus(t *testing.T) {
+	// statuses are updated in the memory repository
+	repo := memory.NewLocationRepository(0, 0)
+	id := domain.NewDriverID()
+	driver := domain.DriverLocation{ID: id, Point: geojson.Point{Type: geojson.TypePoint, Coordinates: geojson.Coordinate{29.0, 41.0}}}
+	if err := repo.UpsertMany(context.Background(), []domain.DriverLocation{driver}); err != nil {
+		t.Fatalf("could not upsert location: %v", err)
+	}
+
+	testCases := []struct {
+		name           string
+		id             string
+		payload        string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "should success with known status",
+			id:             id,
+			payload:        `{"status":"on_trip"}`,
+			expectedStatus: http.StatusOK,
+			expectedCode:   response.SuccessCode,
+		},
+		{
+			name:           "should fail due to unknown status",
+			id:             id,
+			payload:        `{"status":"driving"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeInvalidPayload,
+		},
+		{
+			name:           "should fail due to missing status",
+			id:             id,
+			payload:        `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeInvalidPayload,
+		},
+		{
+			name:           "should fail due to invalid payload",
+			id:             id,
+			payload:        `on_trip`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeInvalidPayload,
+		},
+		{
+			name:           "should fail due to unknown driver",
+			id:             domain.NewDriverID(),
+			payload:        `{"status":"offline"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   response.ErrCodeNotFound,
+		},
+		{
+			name:           "should fail due to invalid id",
+			id:             "driver",
+			payload:        `{"status":"offline"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			locationHandler := newLocationHandler(zap.L(), services.NewLocationService(repo, nil))
+
+			app := fiber.New()
+			app.Put("/driver/:id/status", locationHandler.UpdateStatus)
+			startTestServer(t, app)
+			defer app.Shutdown()
+
+			req, _ := http.NewRequest(http.MethodPut, "http://localhost:8080/driver/"+tc.id+"/status", bytes.NewBufferString(tc.payload))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected status code: %d, got: %d", tc.expectedStatus, resp.StatusCode)
+			}
+
+			var body response.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("cannot decode response body: %v", err)
+			}
+			if body.Code != tc.expectedCode {
+				t.Errorf("expected code: %s, got: %s", tc.expectedCode, body.Code)
+			}
+		})
+	}
+
+	// rejected updates must not change the status
+	onTrip := domain.DriverFilter{Statuses: []domain.DriverStatus{domain.DriverStatusOnTrip}}
+	if _, err := repo.GetNearestDriverLocation(context.Background(), driver, 1000, onTrip); err != nil {
+		t.Errorf("expected driver to be on trip, got: %v", err)
+	}
+}
+
+func TestGetDriverTrail(t *testing.T) {
+	// trails are built from the history of the memory repository
+	repo := memory.NewLocationRepository(0, 0)
+	moving, parked := domain.NewDriverID(), domain.NewDriverID()
+	from := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	for _, locations := range [][]domain.DriverLocation{
+		{
+			{ID: moving, Point: geojson.Point{Type: geojson.TypePoint, Coordinates: geojson.Coordinate{29.0, 41.0}}},
+			{ID: parked, Point: geojson.Point{Type: geojson.TypePoint, Coordinates: geojson.Coordinate{30.0, 40.0}}},
+		},
+		{
+			{ID: moving, Point: geojson.Point{Type: geojson.TypePoint, Coordinates: geojson.Coordinate{29.1, 41.1}}},
+		},
+	} {
+		if err := repo.UpsertMany(context.Background(), locations); err != nil {
+			t.Fatalf("could not upsert locations: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		id             string
+		query          string
+		expectedStatus int
+		expectedCode   string
+		expectedTrail  geojson.Coordinates
+	}{
+		{
+			name:           "should return trail in chronological order",
+			id:             moving,
+			query:          "from=" + from,
+			expectedStatus: http.StatusOK,
+			expectedCode:   response.SuccessCode,
+			expectedTrail:  geojson.Coordinates{{29.0, 41.0}, {29.1, 41.1}},
+		},
+		{
+			name:           "should fail due to single location",
+			id:             parked,
+			query:          "from=" + from,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   response.ErrCodeNotFound,
+		},
+		{
+			name:           "should fail due to unknown driver",
+			id:             domain.NewDriverID(),
+			query:          "from=" + from,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   response.ErrCodeNotFound,
+		},
+		{
+			name:           "should fail due to missing from",
+			id:             moving,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeInvalidQueryParam,
+		},
+		{
+			name:           "should fail due to invalid id",
+			id:             "driver",
+			query:          "from=" + from,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			locationHandler := newLocationHandler(zap.L(), services.NewLocationService(repo, nil))
+
+			app := fiber.New()
+			app.Get("/driver/:id/trail", locationHandler.GetDriverTrail)
+			startTestServer(t, app)
+			defer app.Shutdown()
+
+			resp, err := http.Get("http://localhost:8080/driver/" + tc.id + "/trail?" + tc.query)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected status code: %d, got: %d", tc.expectedStatus, resp.StatusCode)
+			}
+
+			var body struct {
+				response.Response
+				Data geojson.LineString `json:"data"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("cannot decode response body: %v", err)
+			}
+			if body.Code != tc.expectedCode {
+				t.Errorf("expected code: %s, got: %s", tc.expectedCode, body.Code)
+			}
+			if tc.expectedTrail != nil && !reflect.DeepEqual(body.Data.Coordinates, tc.expectedTrail) {
+				t.Errorf("expected trail %v, got: %v", tc.expectedTrail, body.Data.Coordinates)
+			}
+		})
+	}
+}
+
+// startTestServer starts app on localhost:8080 and waits until it accepts connections
+func startTestServer(t *testing.T, app *fiber.App) {
+	t.Helper()
+
+	ready := make(chan struct{})
+	go func() {
+		err := app.Listen("localhost:8080", fiber.ListenConfig{
+			DisableStartupMessage: true,
+			ListenerAddrFunc: func(net.Addr) {
+				close(ready)
+			},
+		})
+		if err != nil {
+			t.Errorf("could not start test server: %v", err)
+		}
+	}()
+
+	// drop keep-alive connections to this server once the test is over
+	t.Cleanup(http.DefaultClient.CloseIdleConnections)
+
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("test server did not start in time")
+	}
+}
@@ -3,6 +3,7 @@ package httphandler
 import (
 	"fmt"
 
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
 	"github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
 	"go.uber.org/zap"
 )
@@ -11,11 +12,26 @@ func (h *Handler) applyRoutes(accessLogger *zap.Logger) {
 	// apply common middlewares
 	h.app.Use(httpfiber.TracingMiddleware)
 	h.app.Use(httpfiber.AccessLogMiddleware(accessLogger))
+	h.app.Use(httpfiber.MetricsMiddleware)
+	h.app.Use(httpfiber.RecoverMiddleware(h.logger))
+
+	// Health API, probes are not authenticated
+	h.app.Get("/healthz", httpfiber.LivenessHandler)
+	h.app.Get("/readyz", httpfiber.ReadinessHandler(h.logger.With(zap.String("handler", "health")), h.healthCfg.ReadinessTimeout, h.healthCfg.ReadinessChecks))
 
 	api := h.app.Group(fmt.Sprintf("/api/%s", h.apiVersion))
 
-	// Driver API
+	// services may call every route, drivers may only push their own location
+	serviceAuth := h.authHandler.Authenticate(domain.ScopeService)
+	driverAuth := h.authHandler.Authenticate(domain.ScopeService, domain.ScopeDriver)
+
+	// Driver API, clients are rate limited by caller identity after authentication
 	driverApi := api.Group("/driver")
-	driverApi.Put("/location", h.locationHandler.AddLocations)
-	driverApi.Post("/location", h.locationHandler.FindNearestDriver)
+	driverApi.Put("/location", h.locationHandler.AddLocations, driverAuth, h.rateLimiter.Middleware("add-locations"))
+	driverApi.Put("/:id/status", h.locationHandler.UpdateStatus, serviceAuth, h.rateLimiter.Middleware("update-status"))
+	driverApi.Get("/:id/trail", h.locationHandler.GetDriverTrail, serviceAuth, h.rateLimiter.Middleware("driver-trail"))
+	driverApi.Get("/:id/stream", h.streamHandler.StreamLocations, driverAuth, h.rateLimiter.Middleware("stream-locations"))
+	driverApi.Post("/location", h.locationHandler.FindNearestDriver, serviceAuth, h.rateLimiter.Middleware("nearest-driver"))
+	driverApi.Post("/locations/nearest", h.locationHandler.FindNearestDrivers, serviceAuth, h.rateLimiter.Middleware("nearest-drivers"))
+	driverApi.Post("/locations/within", h.locationHandler.FindDriversWithin, serviceAuth, h.rateLimiter.Middleware("drivers-within"))
 }
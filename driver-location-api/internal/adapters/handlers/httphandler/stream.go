@@ -0,0 +1,194 @@
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/services"
+	"github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
+	"github.com/aniladanir/bitaksi-casestudy/shared/response"
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+const streamWriteTimeout = 5 * time.Second
+
+type StreamConfig struct {
+	// PingInterval is how often the server pings the connection
+	PingInterval time.Duration
+	// PongTimeout closes the connection if nothing is received from the driver for this long
+	PongTimeout time.Duration
+	// MaxMessageSize is the maximum size of a single location update in bytes
+	MaxMessageSize int64
+}
+
+type streamHandler struct {
+	logger          *zap.Logger
+	locationService services.LocationService
+	batcher         *services.LocationBatcher
+	cfg             StreamConfig
+	upgrader        websocket.FastHTTPUpgrader
+	closing         chan struct{}
+	closeOnce       sync.Once
+}
+
+func newStreamHandler(logger *zap.Logger, locationService services.LocationService, batcher *services.LocationBatcher, cfg StreamConfig) *streamHandler {
+	return &streamHandler{
+		logger:          logger,
+		locationService: locationService,
+		batcher:         batcher,
+		cfg:             cfg,
+		closing:         make(chan struct{}),
+	}
+}
+
+// StreamLocations upgrades the request to a websocket connection that receives location updates of a driver
+func (sh *streamHandler) StreamLocations(ctx fiber.Ctx) error {
+	// the connection outlives the request context, so copy everything needed from it
+	id := strings.Clone(ctx.Params("id"))
+	logger := sh.logger.With(
+		zap.String(httpfiber.CtxKeyTraceID, strings.Clone(ctx.Get(httpfiber.HeaderXTraceID))),
+		zap.String(httpfiber.CtxKeyRequestID, strings.Clone(ctx.Get(httpfiber.HeaderXRequestID))),
+		zap.String("driverId", id),
+	)
+
+	// validate driver id
+	if err := sh.locationService.IsValidID(id); err != nil {
+		logger.Error("invalid driver id", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeBadRequest, response.ErrMsgBadRequest, http.StatusBadRequest)
+	}
+	if principal := principalFromCtx(ctx); principal != nil && !principal.CanUpdateDriver(id) {
+		logger.Error("driver id does not match authenticated driver", zap.String("subject", principal.Subject))
+		return response.Fail(ctx, response.ErrCodeDriverIDMismatch, response.ErrMsgDriverIDMismatch, http.StatusForbidden)
+	}
+	if !websocket.FastHTTPIsWebSocketUpgrade(ctx.Context()) {
+		logger.Error("request is not a websocket upgrade")
+		return response.Fail(ctx, response.ErrCodeBadRequest, response.ErrMsgBadRequest, http.StatusUpgradeRequired)
+	}
+
+	// upgrader writes the error response itself
+	if err := sh.upgrader.Upgrade(ctx.Context(), func(conn *websocket.Conn) {
+		sh.serve(conn, id, logger)
+	}); err != nil {
+		logger.Error("could not upgrade connection", zap.Error(err))
+	}
+
+	return nil
+}
+
+// Close disconnects all streams, it must be called before shutting down the server since
+// fiber does not track hijacked connections
+func (sh *streamHandler) Close() {
+	sh.closeOnce.Do(func() {
+		close(sh.closing)
+	})
+}
+
+func (sh *streamHandler) serve(conn *websocket.Conn, id string, logger *zap.Logger) {
+	defer conn.Close()
+	logger.Info("driver stream connected")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn.SetReadLimit(sh.cfg.MaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(sh.cfg.PongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(sh.cfg.PongTimeout))
+	})
+
+	done := make(chan struct{})
+	defer close(done)
+	go sh.heartbeat(conn, cancel, done)
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.Error("driver stream closed unexpectedly", zap.Error(err))
+			} else {
+				logger.Info("driver stream closed", zap.Error(err))
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(sh.cfg.PongTimeout))
+
+		location, err := parseStreamedLocation(msg)
+		if err != nil {
+			logger.Error("invalid location update", zap.Error(err))
+			if err := sh.writeFail(conn, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload); err != nil {
+				return
+			}
+			continue
+		}
+		location.ID = id
+
+		// blocks while the batcher is full, which stops reading and pushes back on the driver
+		if err := sh.batcher.Submit(ctx, location); err != nil {
+			logger.Error("could not submit location update", zap.Error(err))
+			return
+		}
+	}
+}
+
+// heartbeat pings the connection until done is closed and disconnects it on shutdown
+func (sh *streamHandler) heartbeat(conn *websocket.Conn, cancel context.CancelFunc, done <-chan struct{}) {
+	ticker := time.NewTicker(sh.cfg.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-sh.closing:
+			cancel()
+			conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"),
+				time.Now().Add(streamWriteTimeout),
+			)
+			conn.Close()
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				cancel()
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (sh *streamHandler) writeFail(conn *websocket.Conn, code, msg string) error {
+	conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return conn.WriteJSON(response.Response{
+		Success: false,
+		Code:    code,
+		Message: msg,
+	})
+}
+
+// parseStreamedLocation parses a geojson point with an optional driver status
+func parseStreamedLocation(msg []byte) (domain.DriverLocation, error) {
+	var location domain.DriverLocation
+	if err := json.Unmarshal(msg, &location); err != nil {
+		return location, err
+	}
+	point, err := parsePoint(msg)
+	if err != nil {
+		return location, err
+	}
+	location.Point = point
+	if location.Status != "" {
+		if err := location.Status.IsValid(); err != nil {
+			return location, err
+		}
+	}
+	return location, nil
+}
@@ -0,0 +1,106 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/services"
+	"github.com/aniladanir/bitaksi-casestudy/shared/response"
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+type RecordingLocationService struct {
+	MockLocationService
+	mu        sync.Mutex
+	locations []domain.DriverLocation
+}
+
+func (rls *RecordingLocationService) CreateOrUpdateDriverLocations(ctx context.Context, locations []domain.DriverLocation) error {
+	rls.mu.Lock()
+	defer rls.mu.Unlock()
+	rls.locations = append(rls.locations, locations...)
+	return nil
+}
+
+func (rls *RecordingLocationService) recorded() []domain.DriverLocation {
+	rls.mu.Lock()
+	defer rls.mu.Unlock()
+	return rls.locations
+}
+
+func TestStreamLocations(t *testing.T) {
+	locationService := &RecordingLocationService{MockLocationService: MockLocationService{Valid: true}}
+	batcher := services.NewLocationBatcher(zap.L(), locationService, services.BatcherConfig{
+		FlushInterval: 10 * time.Millisecond,
+		BatchSize:     10,
+		MaxPending:    10,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go batcher.Run(ctx)
+
+	streamHandler := newStreamHandler(zap.L(), locationService, batcher, StreamConfig{
+		PingInterval:   time.Second,
+		PongTimeout:    3 * time.Second,
+		MaxMessageSize: 1024,
+	})
+	app := fiber.New()
+	app.Get("/:id/stream", streamHandler.StreamLocations)
+	startTestServer(t, app)
+	defer app.Shutdown()
+	defer streamHandler.Close()
+
+	t.Run("should fail without websocket upgrade", func(t *testing.T) {
+		resp, err := http.Get("http://localhost:8080/driver-1/stream")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUpgradeRequired {
+			t.Errorf("expected status code: %d, got: %d", http.StatusUpgradeRequired, resp.StatusCode)
+		}
+	})
+
+	t.Run("should flush streamed locations", func(t *testing.T) {
+		conn, _, err := websocket.DefaultDialer.Dial("ws://localhost:8080/driver-1/stream", nil)
+		if err != nil {
+			t.Fatalf("could not dial stream: %v", err)
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+		// invalid updates are answered and the connection stays open
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"Point","coordinates":[29.0]}`)); err != nil {
+			t.Fatalf("could not write message: %v", err)
+		}
+		var body response.Response
+		if err := conn.ReadJSON(&body); err != nil {
+			t.Fatalf("could not read message: %v", err)
+		}
+		if body.Code != response.ErrCodeInvalidPayload {
+			t.Errorf("expected code: %s, got: %s", response.ErrCodeInvalidPayload, body.Code)
+		}
+
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"Point","coordinates":[29.0,41.0],"status":"on_trip"}`)); err != nil {
+			t.Fatalf("could not write message: %v", err)
+		}
+
+		deadline := time.Now().Add(2 * time.Second)
+		for len(locationService.recorded()) == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		locations := locationService.recorded()
+		if len(locations) != 1 {
+			t.Fatalf("expected 1 flushed location, got: %d", len(locations))
+		}
+		if locations[0].ID != "driver-1" || locations[0].Status != domain.DriverStatusOnTrip {
+			t.Errorf("unexpected flushed location: %+v", locations[0])
+		}
+	})
+}
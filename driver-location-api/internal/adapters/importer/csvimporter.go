@@ -54,19 +54,23 @@ func (ci *csvImporter) ImportCoordinates(ctx context.Context, reader io.Reader)
 			return errs.ErrInternal(fmt.Errorf("encountered error when reading %d row: %w", rowNumber, err))
 		}
 		if len(row) != 2 {
+			importedRowsTotal.WithLabelValues(rowResultInvalid).Inc()
 			return errs.ErrInternal(fmt.Errorf("expected number of columns on row %d is incorrect", rowNumber))
 		}
 
 		longtitude, err := strconv.ParseFloat(row[longtitudeIndex], 64)
 		if err != nil {
+			importedRowsTotal.WithLabelValues(rowResultInvalid).Inc()
 			return errs.ErrInternal(fmt.Errorf("could not parse longtitude on row %d: %w", rowNumber, err))
 		}
 		latitude, err := strconv.ParseFloat(row[latitudeIndex], 64)
 		if err != nil {
+			importedRowsTotal.WithLabelValues(rowResultInvalid).Inc()
 			return errs.ErrInternal(fmt.Errorf("could not parse latitude on row %d: %w", rowNumber, err))
 		}
 
 		location := domain.DriverLocation{
+			Status: domain.DriverStatusAvailable,
 			Point: geojson.Point{
 				Type:        geojson.TypePoint,
 				Coordinates: geojson.Coordinate{longtitude, latitude},
@@ -80,8 +84,10 @@ func (ci *csvImporter) ImportCoordinates(ctx context.Context, reader io.Reader)
 
 	if len(locations) > 0 {
 		if err := ci.locationRepo.UpsertMany(ctx, locations); err != nil {
+			importedRowsTotal.WithLabelValues(rowResultFailed).Add(float64(len(locations)))
 			return errs.ErrInternal(err)
 		}
+		importedRowsTotal.WithLabelValues(rowResultImported).Add(float64(len(locations)))
 	}
 
 	return nil
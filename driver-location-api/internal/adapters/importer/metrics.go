@@ -0,0 +1,18 @@
+package importer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Row Results
+const (
+	rowResultImported = "imported"
+	rowResultInvalid  = "invalid"
+	rowResultFailed   = "failed"
+)
+
+var importedRowsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "importer_rows_total",
+	Help: "Number of rows read by the importer by result.",
+}, []string{"result"})
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/adapters/repositories/mongodb"
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
 	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -16,30 +18,39 @@ import (
 
 type LocationRepository interface {
 	UpsertMany(ctx context.Context, locations []domain.DriverLocation) error
-	GetNearestDriverLocation(ctx context.Context, userLocation domain.DriverLocation, radius float64) (*domain.DriverLocation, error)
-	IsValidID(id string) error
+	UpdateStatus(ctx context.Context, id string, status domain.DriverStatus) error
+	GetNearestDriverLocation(ctx context.Context, userLocation domain.DriverLocation, radius float64, filter domain.DriverFilter) (*domain.DriverLocation, error)
+	GetNearestDriverLocations(ctx context.Context, userLocation domain.DriverLocation, radius float64, limit int, filter domain.DriverFilter) ([]domain.DriverLocation, error)
+	GetDriverLocationsWithin(ctx context.Context, area geojson.Geometry, offset, limit int, filter domain.DriverFilter) ([]domain.DriverLocation, int64, error)
+	GetLocationHistory(ctx context.Context, id string, from, to time.Time) ([]domain.DriverLocation, error)
+	// Ping checks that the database is reachable
+	Ping(ctx context.Context) error
 }
 
 type locationRepository struct {
-	driverLocationDB *mongo.Collection
+	driverLocationDB        *mongo.Collection
+	driverLocationHistoryDB *mongo.Collection
 }
 
 func NewLocationRepository(mongoDB *mongo.Database) *locationRepository {
 	return &locationRepository{
-		driverLocationDB: mongoDB.Collection("driver-location"),
+		driverLocationDB:        mongoDB.Collection(mongodb.CollectionDriverLocation),
+		driverLocationHistoryDB: mongoDB.Collection(mongodb.CollectionDriverLocationHistory),
 	}
 }
 
-func (lr *locationRepository) IsValidID(id string) error {
-	if _, err := primitive.ObjectIDFromHex(id); err != nil {
-		return errs.ErrInternal(fmt.Errorf("invalid object id: %w", err))
-	}
-	return nil
+func (lr *locationRepository) Ping(ctx context.Context) error {
+	return lr.driverLocationDB.Database().Client().Ping(ctx, nil)
 }
 
 func (lr *locationRepository) UpsertMany(ctx context.Context, locations []domain.DriverLocation) error {
+	ctx, end := startQuery(ctx, "upsert_many")
+	defer end()
+
 	var err error
+	now := time.Now()
 	models := make([]mongo.WriteModel, 0, len(locations))
+	history := make([]mongodb.DriverLocationHistory, 0, len(locations))
 	for _, l := range locations {
 		var objectID primitive.ObjectID
 		if l.ID == "" {
@@ -50,35 +61,66 @@ func (lr *locationRepository) UpsertMany(ctx context.Context, locations []domain
 				return errs.ErrInternal(fmt.Errorf("invalid location id: %w", err))
 			}
 		}
+		set := bson.M{
+			"_id":       objectID,
+			"location":  l.Point,
+			"updatedAt": now,
+		}
+		update := bson.M{"$set": set}
+		// keep the current status of existing drivers unless a new one is given
+		if l.Status != "" {
+			set["status"] = l.Status
+		} else {
+			update["$setOnInsert"] = bson.M{"status": domain.DriverStatusAvailable}
+		}
 		model := mongo.NewUpdateOneModel().
 			SetFilter(bson.M{"_id": objectID}).
-			SetUpdate(bson.M{"$set": bson.M{
-				"_id":      objectID,
-				"location": l.Point,
-			}}).
+			SetUpdate(update).
 			SetUpsert(true)
 		models = append(models, model)
+		history = append(history, mongodb.DriverLocationHistory{
+			DriverID:  objectID,
+			Location:  l.Point,
+			Timestamp: now,
+		})
 	}
 
 	if _, err := lr.driverLocationDB.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
 		return errs.ErrInternal(fmt.Errorf("failed to bulk write: %w", err))
 	}
+	if _, err := lr.driverLocationHistoryDB.InsertMany(ctx, history, options.InsertMany().SetOrdered(false)); err != nil {
+		return errs.ErrInternal(fmt.Errorf("failed to write location history: %w", err))
+	}
 
 	return nil
 }
 
-func (lr *locationRepository) GetNearestDriverLocation(ctx context.Context, location domain.DriverLocation, radius float64) (*domain.DriverLocation, error) {
-	filter := bson.M{"location": bson.M{
-		"$near": bson.M{
-			"$geometry": bson.M{
-				"type":        location.Type,
-				"coordinates": location.Coordinates,
-			},
-			"$maxDistance": radius,
-		},
-	},
+func (lr *locationRepository) UpdateStatus(ctx context.Context, id string, status domain.DriverStatus) error {
+	ctx, end := startQuery(ctx, "update_status")
+	defer end()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errs.ErrInternal(fmt.Errorf("invalid location id: %w", err))
 	}
 
+	result, err := lr.driverLocationDB.UpdateByID(ctx, objectID, bson.M{"$set": bson.M{"status": status}})
+	if err != nil {
+		return errs.ErrInternal(fmt.Errorf("failed to update driver status: %w", err))
+	}
+	if result.MatchedCount == 0 {
+		return errs.ErrEntityNotFound("driver location")
+	}
+
+	return nil
+}
+
+func (lr *locationRepository) GetNearestDriverLocation(ctx context.Context, location domain.DriverLocation, radius float64, driverFilter domain.DriverFilter) (*domain.DriverLocation, error) {
+	ctx, end := startQuery(ctx, "nearest_driver")
+	defer end()
+
+	filter := nearFilter(location, radius, driverFilter)
+
 	var result mongodb.DriverLocation
 	if err := lr.driverLocationDB.FindOne(ctx, filter).Decode(&result); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
@@ -88,6 +130,145 @@ func (lr *locationRepository) GetNearestDriverLocation(ctx context.Context, loca
 	}
 
 	return &domain.DriverLocation{
-		Point: result.Location,
+		Status:    domain.DriverStatus(result.Status),
+		UpdatedAt: &result.UpdatedAt,
+		Point:     result.Location,
 	}, nil
 }
+
+func (lr *locationRepository) GetNearestDriverLocations(ctx context.Context, location domain.DriverLocation, radius float64, limit int, driverFilter domain.DriverFilter) ([]domain.DriverLocation, error) {
+	ctx, end := startQuery(ctx, "nearest_drivers")
+	defer end()
+
+	filter := nearFilter(location, radius, driverFilter)
+
+	// $near already sorts documents from nearest to farthest
+	cursor, err := lr.driverLocationDB.Find(ctx, filter, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return nil, errs.ErrInternal(err)
+	}
+
+	var results []mongodb.DriverLocation
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, errs.ErrInternal(fmt.Errorf("could not decode driver locations: %w", err))
+	}
+	if len(results) == 0 {
+		return nil, errs.ErrEntityNotFound("driver location")
+	}
+
+	locations := make([]domain.DriverLocation, 0, len(results))
+	for _, r := range results {
+		locations = append(locations, domain.DriverLocation{
+			ID:        r.ID.Hex(),
+			Status:    domain.DriverStatus(r.Status),
+			UpdatedAt: &r.UpdatedAt,
+			Point:     r.Location,
+		})
+	}
+
+	return locations, nil
+}
+
+func (lr *locationRepository) GetDriverLocationsWithin(ctx context.Context, area geojson.Geometry, offset, limit int, driverFilter domain.DriverFilter) ([]domain.DriverLocation, int64, error) {
+	ctx, end := startQuery(ctx, "drivers_within")
+	defer end()
+
+	filter := withDriverFilter(bson.M{"location": bson.M{
+		"$geoWithin": bson.M{
+			"$geometry": area,
+		},
+	},
+	}, driverFilter)
+
+	total, err := lr.driverLocationDB.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, errs.ErrInternal(fmt.Errorf("could not count driver locations: %w", err))
+	}
+
+	// sort by id to keep pages stable between requests
+	opts := options.Find().
+		SetSort(bson.M{"_id": 1}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := lr.driverLocationDB.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, errs.ErrInternal(err)
+	}
+
+	var results []mongodb.DriverLocation
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, 0, errs.ErrInternal(fmt.Errorf("could not decode driver locations: %w", err))
+	}
+
+	locations := make([]domain.DriverLocation, 0, len(results))
+	for _, r := range results {
+		locations = append(locations, domain.DriverLocation{
+			ID:        r.ID.Hex(),
+			Status:    domain.DriverStatus(r.Status),
+			UpdatedAt: &r.UpdatedAt,
+			Point:     r.Location,
+		})
+	}
+
+	return locations, total, nil
+}
+
+func (lr *locationRepository) GetLocationHistory(ctx context.Context, id string, from, to time.Time) ([]domain.DriverLocation, error) {
+	ctx, end := startQuery(ctx, "location_history")
+	defer end()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errs.ErrInternal(fmt.Errorf("invalid location id: %w", err))
+	}
+
+	filter := bson.M{
+		"driverId":  objectID,
+		"timestamp": bson.M{"$gte": from, "$lte": to},
+	}
+	cursor, err := lr.driverLocationHistoryDB.Find(ctx, filter, options.Find().SetSort(bson.M{"timestamp": 1}))
+	if err != nil {
+		return nil, errs.ErrInternal(err)
+	}
+
+	var results []mongodb.DriverLocationHistory
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, errs.ErrInternal(fmt.Errorf("could not decode location history: %w", err))
+	}
+
+	locations := make([]domain.DriverLocation, 0, len(results))
+	for _, r := range results {
+		locations = append(locations, domain.DriverLocation{
+			ID:        r.DriverID.Hex(),
+			UpdatedAt: &r.Timestamp,
+			Point:     r.Location,
+		})
+	}
+
+	return locations, nil
+}
+
+// nearFilter builds a $near query that matches filtered locations within radius meters, sorted by distance
+func nearFilter(location domain.DriverLocation, radius float64, driverFilter domain.DriverFilter) bson.M {
+	return withDriverFilter(bson.M{"location": bson.M{
+		"$near": bson.M{
+			"$geometry": bson.M{
+				"type":        location.Type,
+				"coordinates": location.Coordinates,
+			},
+			"$maxDistance": radius,
+		},
+	},
+	}, driverFilter)
+}
+
+// withDriverFilter adds the conditions of driverFilter to filter
+func withDriverFilter(filter bson.M, driverFilter domain.DriverFilter) bson.M {
+	if len(driverFilter.Statuses) > 0 {
+		filter["status"] = bson.M{"$in": driverFilter.Statuses}
+	}
+	if driverFilter.MaxAge > 0 {
+		filter["updatedAt"] = bson.M{"$gte": time.Now().Add(-driverFilter.MaxAge)}
+	}
+	return filter
+}
@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"math"
+
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
+)
+
+const (
+	// defaultCellSizeDegrees is roughly 1.1 km on the latitude axis
+	defaultCellSizeDegrees = 0.01
+	metersPerDegreeLat     = 111320.0
+)
+
+type cellKey struct {
+	x, y int
+}
+
+// bounds is a longitude/latitude aligned rectangle in degrees
+type bounds struct {
+	minLon, minLat, maxLon, maxLat float64
+}
+
+// grid is a spatial index that buckets ids into fixed size longitude/latitude cells
+type grid struct {
+	cellSize float64
+	cells    map[cellKey]map[string]struct{}
+}
+
+func newGrid(cellSize float64) *grid {
+	return &grid{
+		cellSize: cellSize,
+		cells:    make(map[cellKey]map[string]struct{}),
+	}
+}
+
+func (g *grid) key(lon, lat float64) cellKey {
+	return cellKey{
+		x: int(math.Floor((lon + 180) / g.cellSize)),
+		y: int(math.Floor((lat + 90) / g.cellSize)),
+	}
+}
+
+func (g *grid) insert(id string, lon, lat float64) {
+	k := g.key(lon, lat)
+	cell, ok := g.cells[k]
+	if !ok {
+		cell = make(map[string]struct{})
+		g.cells[k] = cell
+	}
+	cell[id] = struct{}{}
+}
+
+func (g *grid) remove(id string, lon, lat float64) {
+	k := g.key(lon, lat)
+	cell, ok := g.cells[k]
+	if !ok {
+		return
+	}
+	delete(cell, id)
+	if len(cell) == 0 {
+		delete(g.cells, k)
+	}
+}
+
+// search calls fn with every id stored in the cells that intersect b
+func (g *grid) search(b bounds, fn func(id string)) {
+	lower, upper := g.key(b.minLon, b.minLat), g.key(b.maxLon, b.maxLat)
+	cellCount := (upper.x - lower.x + 1) * (upper.y - lower.y + 1)
+
+	// walking the non-empty cells is cheaper than walking a mostly empty area
+	if cellCount > len(g.cells) {
+		for k, cell := range g.cells {
+			if k.x < lower.x || k.x > upper.x || k.y < lower.y || k.y > upper.y {
+				continue
+			}
+			for id := range cell {
+				fn(id)
+			}
+		}
+		return
+	}
+
+	for x := lower.x; x <= upper.x; x++ {
+		for y := lower.y; y <= upper.y; y++ {
+			for id := range g.cells[cellKey{x: x, y: y}] {
+				fn(id)
+			}
+		}
+	}
+}
+
+// radiusBounds returns the rectangle that contains the circle with radius meters around lon/lat
+func radiusBounds(lon, lat, radius float64) bounds {
+	dLat := radius / metersPerDegreeLat
+	b := bounds{
+		minLat: math.Max(lat-dLat, -90),
+		maxLat: math.Min(lat+dLat, 90),
+		minLon: -180,
+		maxLon: 180,
+	}
+
+	// longitude degrees shrink towards the poles, use the widest latitude of the rectangle
+	maxAbsLat := math.Max(math.Abs(b.minLat), math.Abs(b.maxLat))
+	if maxAbsLat >= 90 {
+		return b
+	}
+	dLon := radius / (metersPerDegreeLat * math.Cos(maxAbsLat*math.Pi/180))
+	// the circle crosses the antimeridian, search the whole longitude range
+	if lon-dLon < -180 || lon+dLon > 180 {
+		return b
+	}
+	b.minLon, b.maxLon = lon-dLon, lon+dLon
+	return b
+}
+
+// ringBounds returns the rectangle that contains all coordinates of the rings
+func ringBounds(rings ...geojson.Coordinates) bounds {
+	b := bounds{
+		minLon: math.Inf(1),
+		minLat: math.Inf(1),
+		maxLon: math.Inf(-1),
+		maxLat: math.Inf(-1),
+	}
+	for _, ring := range rings {
+		for _, c := range ring {
+			b.minLon = math.Min(b.minLon, c[0])
+			b.maxLon = math.Max(b.maxLon, c[0])
+			b.minLat = math.Min(b.minLat, c[1])
+			b.maxLat = math.Max(b.maxLat, c[1])
+		}
+	}
+	return b
+}
+
+// inRing reports whether lon/lat is inside the ring using ray casting
+func inRing(lon, lat float64, ring geojson.Coordinates) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// inPolygon reports whether lon/lat is inside the exterior ring and outside of all holes
+func inPolygon(lon, lat float64, rings geojson.MultiCoordinates) bool {
+	if len(rings) == 0 || !inRing(lon, lat, rings[0]) {
+		return false
+	}
+	for _, hole := range rings[1:] {
+		if inRing(lon, lat, hole) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,325 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
+	"github.com/aniladanir/bitaksi-casestudy/shared/haversine"
+)
+
+type driverRecord struct {
+	location  geojson.Point
+	status    domain.DriverStatus
+	updatedAt time.Time
+}
+
+type historyRecord struct {
+	location  geojson.Point
+	timestamp time.Time
+}
+
+// locationRepository keeps driver locations in memory, indexed by a spatial grid.
+// Locations older than locationTTL and history older than historyRetention are
+// ignored and removed by Expire, zero values keep them forever.
+type locationRepository struct {
+	mu               sync.RWMutex
+	index            *grid
+	drivers          map[string]*driverRecord
+	history          map[string][]historyRecord
+	locationTTL      time.Duration
+	historyRetention time.Duration
+}
+
+func NewLocationRepository(locationTTL, historyRetention time.Duration) *locationRepository {
+	return &locationRepository{
+		index:            newGrid(defaultCellSizeDegrees),
+		drivers:          make(map[string]*driverRecord),
+		history:          make(map[string][]historyRecord),
+		locationTTL:      locationTTL,
+		historyRetention: historyRetention,
+	}
+}
+
+// Ping always succeeds as there is no database to reach
+func (lr *locationRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (lr *locationRepository) UpsertMany(ctx context.Context, locations []domain.DriverLocation) error {
+	// validate ids before touching the index so that a bad batch has no effect
+	ids := make([]string, len(locations))
+	for i, l := range locations {
+		if !l.Point.IsValid() {
+			return errs.ErrInternal(fmt.Errorf("invalid location on element %d", i+1))
+		}
+		if l.ID == "" {
+			ids[i] = domain.NewDriverID()
+			continue
+		}
+		if err := domain.ValidateDriverID(l.ID); err != nil {
+			return errs.ErrInternal(fmt.Errorf("invalid id: %w", err))
+		}
+		ids[i] = l.ID
+	}
+
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	now := time.Now()
+	for i, l := range locations {
+		id := ids[i]
+		record, ok := lr.drivers[id]
+		if ok {
+			lr.index.remove(id, record.location.Coordinates[0], record.location.Coordinates[1])
+		} else {
+			// new drivers are available unless a status is given
+			record = &driverRecord{status: domain.DriverStatusAvailable}
+			lr.drivers[id] = record
+		}
+		record.location = l.Point
+		record.updatedAt = now
+		if l.Status != "" {
+			record.status = l.Status
+		}
+		lr.index.insert(id, l.Coordinates[0], l.Coordinates[1])
+
+		lr.history[id] = append(lr.pruneHistory(lr.history[id], now), historyRecord{
+			location:  l.Point,
+			timestamp: now,
+		})
+	}
+
+	return nil
+}
+
+func (lr *locationRepository) UpdateStatus(ctx context.Context, id string, status domain.DriverStatus) error {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	record, ok := lr.drivers[id]
+	if !ok || lr.isExpired(record, time.Now()) {
+		return errs.ErrEntityNotFound("driver location")
+	}
+	record.status = status
+
+	return nil
+}
+
+func (lr *locationRepository) GetNearestDriverLocation(ctx context.Context, location domain.DriverLocation, radius float64, filter domain.DriverFilter) (*domain.DriverLocation, error) {
+	locations, err := lr.GetNearestDriverLocations(ctx, location, radius, 1, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// ids are not returned by the single nearest lookup
+	locations[0].ID = ""
+	return &locations[0], nil
+}
+
+func (lr *locationRepository) GetNearestDriverLocations(ctx context.Context, location domain.DriverLocation, radius float64, limit int, filter domain.DriverFilter) ([]domain.DriverLocation, error) {
+	type candidate struct {
+		id       string
+		distance float64
+	}
+
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+
+	var searchErr error
+	candidates := make([]candidate, 0, limit)
+	now := time.Now()
+	lr.index.search(radiusBounds(location.Coordinates[0], location.Coordinates[1], radius), func(id string) {
+		record := lr.drivers[id]
+		if searchErr != nil || !lr.matches(record, filter, now) {
+			return
+		}
+		distanceKM, err := haversine.HaversineDistanceInKM(location.Point, record.location)
+		if err != nil {
+			searchErr = err
+			return
+		}
+		if distanceKM*1000 <= radius {
+			candidates = append(candidates, candidate{id: id, distance: distanceKM})
+		}
+	})
+	if searchErr != nil {
+		return nil, errs.ErrInternal(fmt.Errorf("could not calculate distance between points: %w", searchErr))
+	}
+	if len(candidates) == 0 {
+		return nil, errs.ErrEntityNotFound("driver location")
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	locations := make([]domain.DriverLocation, 0, len(candidates))
+	for _, c := range candidates {
+		locations = append(locations, lr.toDomain(c.id, lr.drivers[c.id]))
+	}
+
+	return locations, nil
+}
+
+func (lr *locationRepository) GetDriverLocationsWithin(ctx context.Context, area geojson.Geometry, offset, limit int, filter domain.DriverFilter) ([]domain.DriverLocation, int64, error) {
+	var polygons []geojson.MultiCoordinates
+	switch a := area.(type) {
+	case geojson.Polygon:
+		polygons = []geojson.MultiCoordinates{a.Coordinates}
+	case geojson.MultiPolygon:
+		polygons = a.Coordinates
+	default:
+		return nil, 0, errs.ErrInternal(errors.New("area must be a polygon or multipolygon"))
+	}
+
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+
+	var ids []string
+	now := time.Now()
+	for _, polygon := range polygons {
+		if len(polygon) == 0 {
+			continue
+		}
+		lr.index.search(ringBounds(polygon[0]), func(id string) {
+			record := lr.drivers[id]
+			if !lr.matches(record, filter, now) {
+				return
+			}
+			if inPolygon(record.location.Coordinates[0], record.location.Coordinates[1], polygon) {
+				ids = append(ids, id)
+			}
+		})
+	}
+
+	// a driver can be inside more than one polygon, sort by id to deduplicate and keep pages stable
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	total := int64(len(ids))
+	if offset >= len(ids) {
+		return []domain.DriverLocation{}, total, nil
+	}
+	ids = ids[offset:min(offset+limit, len(ids))]
+
+	locations := make([]domain.DriverLocation, 0, len(ids))
+	for _, id := range ids {
+		locations = append(locations, lr.toDomain(id, lr.drivers[id]))
+	}
+
+	return locations, total, nil
+}
+
+func (lr *locationRepository) GetLocationHistory(ctx context.Context, id string, from, to time.Time) ([]domain.DriverLocation, error) {
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+
+	now := time.Now()
+	locations := make([]domain.DriverLocation, 0)
+	for _, h := range lr.history[id] {
+		if h.timestamp.Before(from) || h.timestamp.After(to) || lr.isHistoryExpired(h, now) {
+			continue
+		}
+		timestamp := h.timestamp
+		locations = append(locations, domain.DriverLocation{
+			ID:        id,
+			UpdatedAt: &timestamp,
+			Point:     h.location,
+		})
+	}
+
+	return locations, nil
+}
+
+// Expire removes expired locations and history every interval until ctx is done
+func (lr *locationRepository) Expire(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		lr.expire(time.Now())
+	}
+}
+
+// expire removes the drivers past location ttl from the index and the history past retention,
+// drivers that stopped reporting would be kept forever otherwise
+func (lr *locationRepository) expire(now time.Time) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	if lr.locationTTL > 0 {
+		for id, record := range lr.drivers {
+			if lr.isExpired(record, now) {
+				lr.index.remove(id, record.location.Coordinates[0], record.location.Coordinates[1])
+				delete(lr.drivers, id)
+			}
+		}
+	}
+	if lr.historyRetention > 0 {
+		for id, history := range lr.history {
+			pruned := lr.pruneHistory(history, now)
+			switch {
+			case len(pruned) == 0:
+				delete(lr.history, id)
+			case len(pruned) < len(history):
+				// copy so that the expired records are released with the old array
+				lr.history[id] = slices.Clone(pruned)
+			}
+		}
+	}
+}
+
+func (lr *locationRepository) matches(record *driverRecord, filter domain.DriverFilter, now time.Time) bool {
+	if lr.isExpired(record, now) {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, record.status) {
+		return false
+	}
+	if filter.MaxAge > 0 && now.Sub(record.updatedAt) > filter.MaxAge {
+		return false
+	}
+	return true
+}
+
+func (lr *locationRepository) isExpired(record *driverRecord, now time.Time) bool {
+	return lr.locationTTL > 0 && now.Sub(record.updatedAt) > lr.locationTTL
+}
+
+func (lr *locationRepository) isHistoryExpired(h historyRecord, now time.Time) bool {
+	return lr.historyRetention > 0 && now.Sub(h.timestamp) > lr.historyRetention
+}
+
+// pruneHistory drops the expired records from the beginning of the chronologically ordered history
+func (lr *locationRepository) pruneHistory(history []historyRecord, now time.Time) []historyRecord {
+	i := 0
+	for i < len(history) && lr.isHistoryExpired(history[i], now) {
+		i++
+	}
+	return history[i:]
+}
+
+func (lr *locationRepository) toDomain(id string, record *driverRecord) domain.DriverLocation {
+	updatedAt := record.updatedAt
+	return domain.DriverLocation{
+		ID:        id,
+		Status:    record.status,
+		UpdatedAt: &updatedAt,
+		Point:     record.location,
+	}
+}
@@ -0,0 +1,283 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
+)
+
+func point(lon, lat float64) geojson.Point {
+	return geojson.Point{
+		Type:        geojson.TypePoint,
+		Coordinates: geojson.Coordinate{lon, lat},
+	}
+}
+
+func newTestRepository(t *testing.T, locations ...domain.DriverLocation) *locationRepository {
+	t.Helper()
+
+	repo := NewLocationRepository(0, 0)
+	if err := repo.UpsertMany(context.Background(), locations); err != nil {
+		t.Fatalf("could not upsert locations: %v", err)
+	}
+	return repo
+}
+
+func TestGetNearestDriverLocations(t *testing.T) {
+	near, middle, far := domain.NewDriverID(), domain.NewDriverID(), domain.NewDriverID()
+	onTrip, outOfRadius := domain.NewDriverID(), domain.NewDriverID()
+	repo := newTestRepository(t,
+		domain.DriverLocation{ID: far, Point: point(29.03, 41.0)},
+		domain.DriverLocation{ID: near, Point: point(29.001, 41.0)},
+		domain.DriverLocation{ID: onTrip, Status: domain.DriverStatusOnTrip, Point: point(29.0005, 41.0)},
+		domain.DriverLocation{ID: middle, Point: point(29.01, 41.0)},
+		domain.DriverLocation{ID: outOfRadius, Point: point(29.5, 41.0)},
+	)
+	userLocation := domain.DriverLocation{Point: point(29.0, 41.0)}
+	available := domain.DriverFilter{Statuses: []domain.DriverStatus{domain.DriverStatusAvailable}}
+
+	testCases := []struct {
+		name        string
+		radius      float64
+		limit       int
+		filter      domain.DriverFilter
+		expectedIDs []string
+	}{
+		{
+			name:        "should return available drivers ordered by distance",
+			radius:      5000,
+			limit:       10,
+			filter:      available,
+			expectedIDs: []string{near, middle, far},
+		},
+		{
+			name:        "should return drivers of any status without filter",
+			radius:      5000,
+			limit:       10,
+			expectedIDs: []string{onTrip, near, middle, far},
+		},
+		{
+			name:        "should respect limit",
+			radius:      5000,
+			limit:       2,
+			filter:      available,
+			expectedIDs: []string{near, middle},
+		},
+		{
+			name:        "should respect radius",
+			radius:      1000,
+			limit:       10,
+			filter:      available,
+			expectedIDs: []string{near, middle},
+		},
+		{
+			name:        "should search the whole world with huge radius",
+			radius:      1e10,
+			limit:       10,
+			filter:      available,
+			expectedIDs: []string{near, middle, far, outOfRadius},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			locations, err := repo.GetNearestDriverLocations(context.Background(), userLocation, tc.radius, tc.limit, tc.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(locations) != len(tc.expectedIDs) {
+				t.Fatalf("expected %d locations, got: %d", len(tc.expectedIDs), len(locations))
+			}
+			for i, l := range locations {
+				if l.ID != tc.expectedIDs[i] {
+					t.Errorf("expected id %s at %d, got: %s", tc.expectedIDs[i], i, l.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestGetNearestDriverLocationNotFound(t *testing.T) {
+	repo := newTestRepository(t, domain.DriverLocation{Point: point(29.5, 41.0)})
+
+	_, err := repo.GetNearestDriverLocation(context.Background(), domain.DriverLocation{Point: point(29.0, 41.0)}, 1000, domain.DriverFilter{})
+	if !errs.IsEntityNotFoundErr(err) {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+}
+
+func TestUpsertManyMovesDriver(t *testing.T) {
+	id := domain.NewDriverID()
+	repo := newTestRepository(t, domain.DriverLocation{ID: id, Status: domain.DriverStatusBreak, Point: point(29.0, 41.0)})
+
+	// move the driver far away without a status, previous status should be kept
+	if err := repo.UpsertMany(context.Background(), []domain.DriverLocation{{ID: id, Point: point(32.8, 39.9)}}); err != nil {
+		t.Fatalf("could not upsert location: %v", err)
+	}
+
+	if _, err := repo.GetNearestDriverLocation(context.Background(), domain.DriverLocation{Point: point(29.0, 41.0)}, 1000, domain.DriverFilter{}); !errs.IsEntityNotFoundErr(err) {
+		t.Errorf("expected driver to leave its previous location, got: %v", err)
+	}
+	location, err := repo.GetNearestDriverLocation(context.Background(), domain.DriverLocation{Point: point(32.8, 39.9)}, 1000, domain.DriverFilter{})
+	if err != nil {
+		t.Fatalf("expected driver at its new location, got: %v", err)
+	}
+	if location.Status != domain.DriverStatusBreak {
+		t.Errorf("expected status %s, got: %s", domain.DriverStatusBreak, location.Status)
+	}
+}
+
+func TestUpsertManyIDs(t *testing.T) {
+	repo := newTestRepository(t)
+
+	// ids are object ids like in mongodb and postgres
+	if err := repo.UpsertMany(context.Background(), []domain.DriverLocation{{ID: "65a1f0c2e4b0a1b2c3d4e5f6", Point: point(29.0, 41.0)}}); err != nil {
+		t.Errorf("expected object id to be accepted, got: %v", err)
+	}
+	if err := repo.UpsertMany(context.Background(), []domain.DriverLocation{{ID: "0b9f3c1e-5a7d-4c2b-9e8f-1a2b3c4d5e6f", Point: point(29.0, 41.0)}}); err == nil {
+		t.Error("expected uuid to be rejected")
+	}
+}
+
+func TestGetDriverLocationsWithin(t *testing.T) {
+	inside, inHole, outside := domain.NewDriverID(), domain.NewDriverID(), domain.NewDriverID()
+	repo := newTestRepository(t,
+		domain.DriverLocation{ID: inside, Point: point(29.01, 41.01)},
+		domain.DriverLocation{ID: inHole, Point: point(29.05, 41.05)},
+		domain.DriverLocation{ID: outside, Point: point(29.2, 41.2)},
+	)
+	area := geojson.Polygon{
+		Type: geojson.TypePolygon,
+		Coordinates: geojson.MultiCoordinates{
+			{{29.0, 41.0}, {29.1, 41.0}, {29.1, 41.1}, {29.0, 41.1}, {29.0, 41.0}},
+			{{29.04, 41.04}, {29.06, 41.04}, {29.06, 41.06}, {29.04, 41.06}, {29.04, 41.04}},
+		},
+	}
+
+	locations, total, err := repo.GetDriverLocationsWithin(context.Background(), area, 0, 10, domain.DriverFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 1 || len(locations) != 1 || locations[0].ID != inside {
+		t.Errorf("expected only %s inside the area, got: %+v", inside, locations)
+	}
+
+	locations, total, err = repo.GetDriverLocationsWithin(context.Background(), area, 1, 10, domain.DriverFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 1 || len(locations) != 0 {
+		t.Errorf("expected an empty page, got: %+v", locations)
+	}
+}
+
+func TestGetLocationHistory(t *testing.T) {
+	id := domain.NewDriverID()
+	from := time.Now()
+	repo := newTestRepository(t, domain.DriverLocation{ID: id, Point: point(29.0, 41.0)})
+	if err := repo.UpsertMany(context.Background(), []domain.DriverLocation{{ID: id, Point: point(29.1, 41.1)}}); err != nil {
+		t.Fatalf("could not upsert location: %v", err)
+	}
+
+	history, err := repo.GetLocationHistory(context.Background(), id, from, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 history records, got: %d", len(history))
+	}
+	if history[0].Coordinates[0] != 29.0 || history[1].Coordinates[0] != 29.1 {
+		t.Errorf("expected history in chronological order, got: %+v", history)
+	}
+}
+
+func TestUpdateStatusNotFound(t *testing.T) {
+	repo := newTestRepository(t)
+
+	if err := repo.UpdateStatus(context.Background(), domain.NewDriverID(), domain.DriverStatusOffline); !errs.IsEntityNotFoundErr(err) {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+}
+
+func TestGetNearestDriverLocationsMaxAge(t *testing.T) {
+	fresh, stale := domain.NewDriverID(), domain.NewDriverID()
+	repo := newTestRepository(t,
+		domain.DriverLocation{ID: fresh, Point: point(29.01, 41.0)},
+		domain.DriverLocation{ID: stale, Point: point(29.001, 41.0)},
+	)
+	repo.drivers[stale].updatedAt = time.Now().Add(-time.Hour)
+	userLocation := domain.DriverLocation{Point: point(29.0, 41.0)}
+
+	testCases := []struct {
+		name        string
+		maxAge      time.Duration
+		expectedIDs []string
+	}{
+		{
+			name:        "should exclude drivers not updated within max age",
+			maxAge:      time.Minute,
+			expectedIDs: []string{fresh},
+		},
+		{
+			name:        "should include drivers updated within max age",
+			maxAge:      2 * time.Hour,
+			expectedIDs: []string{stale, fresh},
+		},
+		{
+			name:        "should include all drivers without max age",
+			expectedIDs: []string{stale, fresh},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			locations, err := repo.GetNearestDriverLocations(context.Background(), userLocation, 5000, 10, domain.DriverFilter{MaxAge: tc.maxAge})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(locations) != len(tc.expectedIDs) {
+				t.Fatalf("expected %d locations, got: %d", len(tc.expectedIDs), len(locations))
+			}
+			for i, l := range locations {
+				if l.ID != tc.expectedIDs[i] {
+					t.Errorf("expected id %s at %d, got: %s", tc.expectedIDs[i], i, l.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestExpire(t *testing.T) {
+	fresh, stale := domain.NewDriverID(), domain.NewDriverID()
+	repo := NewLocationRepository(time.Hour, time.Hour)
+	if err := repo.UpsertMany(context.Background(), []domain.DriverLocation{
+		{ID: fresh, Point: point(29.01, 41.0)},
+		{ID: stale, Point: point(29.001, 41.0)},
+	}); err != nil {
+		t.Fatalf("could not upsert locations: %v", err)
+	}
+	repo.drivers[stale].updatedAt = time.Now().Add(-2 * time.Hour)
+	repo.history[stale][0].timestamp = time.Now().Add(-2 * time.Hour)
+	repo.history[fresh] = append([]historyRecord{{location: point(29.0, 41.0), timestamp: time.Now().Add(-2 * time.Hour)}}, repo.history[fresh]...)
+
+	repo.expire(time.Now())
+
+	if _, ok := repo.drivers[stale]; ok {
+		t.Error("expected stale driver to be removed")
+	}
+	if _, ok := repo.history[stale]; ok {
+		t.Error("expected expired history of stale driver to be removed")
+	}
+	if len(repo.history[fresh]) != 1 {
+		t.Errorf("expected expired history of fresh driver to be dropped, got: %d records", len(repo.history[fresh]))
+	}
+	var indexed []string
+	repo.index.search(radiusBounds(29.0, 41.0, 5000), func(id string) { indexed = append(indexed, id) })
+	if len(indexed) != 1 || indexed[0] != fresh {
+		t.Errorf("expected only fresh driver in the index, got: %v", indexed)
+	}
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/adapters/repositories"
+
+var mongoQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "mongo_query_duration_seconds",
+	Help:    "Latency of mongo queries of the location repository by operation.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"operation"})
+
+// startQuery starts the span of a query, the returned function ends it and records the latency, it is meant to be deferred
+func startQuery(ctx context.Context, operation string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "mongodb "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "mongodb"),
+			attribute.String("db.operation.name", operation),
+		),
+	)
+	return ctx, func() {
+		span.End()
+		mongoQueryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	}
+}
@@ -1,11 +1,21 @@
 package mongodb
 
 import (
+	"time"
+
 	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type DriverLocation struct {
-	ID       primitive.ObjectID `bson:"_id"`
-	Location geojson.Point      `bson:"location"`
+	ID        primitive.ObjectID `bson:"_id"`
+	Location  geojson.Point      `bson:"location"`
+	Status    string             `bson:"status,omitempty"`
+	UpdatedAt time.Time          `bson:"updatedAt"`
+}
+
+type DriverLocationHistory struct {
+	DriverID  primitive.ObjectID `bson:"driverId"`
+	Location  geojson.Point      `bson:"location"`
+	Timestamp time.Time          `bson:"timestamp"`
 }
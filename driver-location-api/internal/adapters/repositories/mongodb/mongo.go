@@ -2,26 +2,106 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+const (
+	CollectionDriverLocation        = "driver-location"
+	CollectionDriverLocationHistory = "driver-location-history"
+)
+
+const (
+	// codeNamespaceExists is returned when a collection with the same name already exists
+	codeNamespaceExists = 48
+	// codeIndexOptionsConflict is returned when an index exists with the same name but different options
+	codeIndexOptionsConflict = 85
+)
+
 func NewMongoClient(uri string) (*mongo.Client, error) {
 	opts := options.Client().ApplyURI(uri)
 	return mongo.Connect(opts)
 }
 
-func CreateDatabase(ctx context.Context, client *mongo.Client, dbName string) (*mongo.Database, error) {
+func CreateDatabase(ctx context.Context, client *mongo.Client, dbName string, locationTTL, historyRetention time.Duration) (*mongo.Database, error) {
 	db := client.Database(dbName)
 	indexModel := mongo.IndexModel{
 		Keys:    bson.M{"location": "2dsphere"},
 		Options: options.Index().SetName("location_2dsphere"),
 	}
-	if _, err := db.Collection("driver-location").Indexes().CreateOne(ctx, indexModel); err != nil {
+	if _, err := db.Collection(CollectionDriverLocation).Indexes().CreateOne(ctx, indexModel); err != nil {
 		return nil, fmt.Errorf("could not create 2dsphere index on driver-location collection: %w", err)
 	}
+	if locationTTL > 0 {
+		if err := createTTLIndex(ctx, db, CollectionDriverLocation, "updatedAt", locationTTL); err != nil {
+			return nil, fmt.Errorf("could not create ttl index on driver-location collection: %w", err)
+		}
+	}
+	if err := createHistoryCollection(ctx, db, historyRetention); err != nil {
+		return nil, fmt.Errorf("could not create driver-location-history collection: %w", err)
+	}
 	return client.Database(dbName), nil
 }
+
+// createHistoryCollection creates the time series collection that keeps past driver locations.
+// Documents older than retention are removed by mongodb, zero retention keeps them forever.
+func createHistoryCollection(ctx context.Context, db *mongo.Database, retention time.Duration) error {
+	opts := options.CreateCollection().SetTimeSeriesOptions(
+		options.TimeSeries().
+			SetTimeField("timestamp").
+			SetMetaField("driverId").
+			SetGranularity("seconds"),
+	)
+	if retention > 0 {
+		opts.SetExpireAfterSeconds(int64(retention.Seconds()))
+	}
+	err := db.CreateCollection(ctx, CollectionDriverLocationHistory, opts)
+	if err == nil {
+		return nil
+	}
+
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Code != codeNamespaceExists {
+		return err
+	}
+	// collection already exists, keep its retention in sync with configuration
+	var expireAfterSeconds any = "off"
+	if retention > 0 {
+		expireAfterSeconds = int64(retention.Seconds())
+	}
+	return db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: CollectionDriverLocationHistory},
+		{Key: "expireAfterSeconds", Value: expireAfterSeconds},
+	}).Err()
+}
+
+// createTTLIndex creates a ttl index on field, or updates its expiry if the index already exists with another ttl
+func createTTLIndex(ctx context.Context, db *mongo.Database, collection, field string, ttl time.Duration) error {
+	name := field + "_ttl"
+	expireAfterSeconds := int32(ttl.Seconds())
+	indexModel := mongo.IndexModel{
+		Keys:    bson.M{field: 1},
+		Options: options.Index().SetName(name).SetExpireAfterSeconds(expireAfterSeconds),
+	}
+	_, err := db.Collection(collection).Indexes().CreateOne(ctx, indexModel)
+	if err == nil {
+		return nil
+	}
+
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Code != codeIndexOptionsConflict {
+		return err
+	}
+	return db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "index", Value: bson.D{
+			{Key: "name", Value: name},
+			{Key: "expireAfterSeconds", Value: expireAfterSeconds},
+		}},
+	}).Err()
+}
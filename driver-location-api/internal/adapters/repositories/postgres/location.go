@@ -0,0 +1,288 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	upsertLocationQuery = `
+		INSERT INTO driver_location (id, location, status, updated_at)
+		VALUES ($1, ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography, COALESCE(NULLIF($4::text, ''), 'available'), $5)
+		ON CONFLICT (id) DO UPDATE SET
+			location = EXCLUDED.location,
+			updated_at = EXCLUDED.updated_at,
+			status = COALESCE(NULLIF($4::text, ''), driver_location.status)`
+
+	insertHistoryQuery = `
+		INSERT INTO driver_location_history (driver_id, location, recorded_at)
+		VALUES ($1, ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography, $4)`
+
+	updateStatusQuery = `
+		UPDATE driver_location SET status = $2
+		WHERE id = $1 AND ($3::timestamptz IS NULL OR updated_at >= $3)`
+
+	// driver filter conditions expect statuses as $1 and minimum update time as $2
+	nearestLocationsQuery = `
+		SELECT id, ST_X(location::geometry), ST_Y(location::geometry), status, updated_at
+		FROM driver_location
+		WHERE (cardinality($1::text[]) = 0 OR status = ANY($1))
+			AND ($2::timestamptz IS NULL OR updated_at >= $2)
+			AND ST_DWithin(location, ST_SetSRID(ST_MakePoint($3, $4), 4326)::geography, $5)
+		ORDER BY location <-> ST_SetSRID(ST_MakePoint($3, $4), 4326)::geography
+		LIMIT $6`
+
+	countLocationsWithinQuery = `
+		SELECT count(*)
+		FROM driver_location
+		WHERE (cardinality($1::text[]) = 0 OR status = ANY($1))
+			AND ($2::timestamptz IS NULL OR updated_at >= $2)
+			AND ST_Covers(ST_GeomFromGeoJSON($3)::geography, location)`
+
+	locationsWithinQuery = `
+		SELECT id, ST_X(location::geometry), ST_Y(location::geometry), status, updated_at
+		FROM driver_location
+		WHERE (cardinality($1::text[]) = 0 OR status = ANY($1))
+			AND ($2::timestamptz IS NULL OR updated_at >= $2)
+			AND ST_Covers(ST_GeomFromGeoJSON($3)::geography, location)
+		ORDER BY id
+		OFFSET $4
+		LIMIT $5`
+
+	locationHistoryQuery = `
+		SELECT driver_id, ST_X(location::geometry), ST_Y(location::geometry), recorded_at
+		FROM driver_location_history
+		WHERE driver_id = $1
+			AND recorded_at BETWEEN $2 AND $3
+			AND ($4::timestamptz IS NULL OR recorded_at >= $4)
+		ORDER BY recorded_at`
+
+	deleteExpiredLocationsQuery = `DELETE FROM driver_location WHERE updated_at < $1`
+	deleteExpiredHistoryQuery   = `DELETE FROM driver_location_history WHERE recorded_at < $1`
+)
+
+// locationRepository keeps driver locations in postgis. Postgres has no ttl indexes, so
+// locations older than locationTTL and history older than historyRetention are ignored
+// by queries and removed by Expire, zero values keep them forever.
+type locationRepository struct {
+	pool             *pgxpool.Pool
+	locationTTL      time.Duration
+	historyRetention time.Duration
+}
+
+func NewLocationRepository(pool *pgxpool.Pool, locationTTL, historyRetention time.Duration) *locationRepository {
+	return &locationRepository{
+		pool:             pool,
+		locationTTL:      locationTTL,
+		historyRetention: historyRetention,
+	}
+}
+
+func (lr *locationRepository) Ping(ctx context.Context) error {
+	return lr.pool.Ping(ctx)
+}
+
+func (lr *locationRepository) UpsertMany(ctx context.Context, locations []domain.DriverLocation) error {
+	now := time.Now()
+	batch := &pgx.Batch{}
+	for i, l := range locations {
+		if !l.Point.IsValid() {
+			return errs.ErrInternal(fmt.Errorf("invalid location on element %d", i+1))
+		}
+		id := l.ID
+		if id == "" {
+			id = domain.NewDriverID()
+		} else if err := domain.ValidateDriverID(id); err != nil {
+			return errs.ErrInternal(fmt.Errorf("invalid id: %w", err))
+		}
+		lon, lat := l.Coordinates[0], l.Coordinates[1]
+		batch.Queue(upsertLocationQuery, id, lon, lat, string(l.Status), now)
+		batch.Queue(insertHistoryQuery, id, lon, lat, now)
+	}
+
+	err := pgx.BeginFunc(ctx, lr.pool, func(tx pgx.Tx) error {
+		return tx.SendBatch(ctx, batch).Close()
+	})
+	if err != nil {
+		return errs.ErrInternal(fmt.Errorf("failed to upsert locations: %w", err))
+	}
+
+	return nil
+}
+
+func (lr *locationRepository) UpdateStatus(ctx context.Context, id string, status domain.DriverStatus) error {
+	tag, err := lr.pool.Exec(ctx, updateStatusQuery, id, string(status), lr.minUpdatedAt(0))
+	if err != nil {
+		return errs.ErrInternal(fmt.Errorf("failed to update driver status: %w", err))
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrEntityNotFound("driver location")
+	}
+
+	return nil
+}
+
+func (lr *locationRepository) GetNearestDriverLocation(ctx context.Context, location domain.DriverLocation, radius float64, filter domain.DriverFilter) (*domain.DriverLocation, error) {
+	locations, err := lr.GetNearestDriverLocations(ctx, location, radius, 1, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// ids are not returned by the single nearest lookup
+	locations[0].ID = ""
+	return &locations[0], nil
+}
+
+func (lr *locationRepository) GetNearestDriverLocations(ctx context.Context, location domain.DriverLocation, radius float64, limit int, filter domain.DriverFilter) ([]domain.DriverLocation, error) {
+	rows, err := lr.pool.Query(ctx, nearestLocationsQuery,
+		statuses(filter),
+		lr.minUpdatedAt(filter.MaxAge),
+		location.Coordinates[0],
+		location.Coordinates[1],
+		radius,
+		limit,
+	)
+	if err != nil {
+		return nil, errs.ErrInternal(err)
+	}
+
+	locations, err := pgx.CollectRows(rows, scanDriverLocation)
+	if err != nil {
+		return nil, errs.ErrInternal(fmt.Errorf("could not scan driver locations: %w", err))
+	}
+	if len(locations) == 0 {
+		return nil, errs.ErrEntityNotFound("driver location")
+	}
+
+	return locations, nil
+}
+
+func (lr *locationRepository) GetDriverLocationsWithin(ctx context.Context, area geojson.Geometry, offset, limit int, filter domain.DriverFilter) ([]domain.DriverLocation, int64, error) {
+	areaJson, err := json.Marshal(area)
+	if err != nil {
+		return nil, 0, errs.ErrInternal(fmt.Errorf("could not marshal area: %w", err))
+	}
+	minUpdatedAt := lr.minUpdatedAt(filter.MaxAge)
+
+	var total int64
+	if err := lr.pool.QueryRow(ctx, countLocationsWithinQuery, statuses(filter), minUpdatedAt, string(areaJson)).Scan(&total); err != nil {
+		return nil, 0, errs.ErrInternal(fmt.Errorf("could not count driver locations: %w", err))
+	}
+
+	rows, err := lr.pool.Query(ctx, locationsWithinQuery, statuses(filter), minUpdatedAt, string(areaJson), offset, limit)
+	if err != nil {
+		return nil, 0, errs.ErrInternal(err)
+	}
+	locations, err := pgx.CollectRows(rows, scanDriverLocation)
+	if err != nil {
+		return nil, 0, errs.ErrInternal(fmt.Errorf("could not scan driver locations: %w", err))
+	}
+
+	return locations, total, nil
+}
+
+func (lr *locationRepository) GetLocationHistory(ctx context.Context, id string, from, to time.Time) ([]domain.DriverLocation, error) {
+	var minRecordedAt *time.Time
+	if lr.historyRetention > 0 {
+		t := time.Now().Add(-lr.historyRetention)
+		minRecordedAt = &t
+	}
+
+	rows, err := lr.pool.Query(ctx, locationHistoryQuery, id, from, to, minRecordedAt)
+	if err != nil {
+		return nil, errs.ErrInternal(err)
+	}
+
+	locations, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.DriverLocation, error) {
+		var l domain.DriverLocation
+		var lon, lat float64
+		var recordedAt time.Time
+		if err := row.Scan(&l.ID, &lon, &lat, &recordedAt); err != nil {
+			return l, err
+		}
+		l.UpdatedAt = &recordedAt
+		l.Point = geojson.Point{
+			Type:        geojson.TypePoint,
+			Coordinates: geojson.Coordinate{lon, lat},
+		}
+		return l, nil
+	})
+	if err != nil {
+		return nil, errs.ErrInternal(fmt.Errorf("could not scan location history: %w", err))
+	}
+
+	return locations, nil
+}
+
+// Expire deletes expired locations and history every interval until ctx is done, errors are passed to onError
+// and the deletion is retried on the next tick
+func (lr *locationRepository) Expire(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		if lr.locationTTL > 0 {
+			if _, err := lr.pool.Exec(ctx, deleteExpiredLocationsQuery, now.Add(-lr.locationTTL)); err != nil && ctx.Err() == nil {
+				onError(fmt.Errorf("could not delete expired locations: %w", err))
+			}
+		}
+		if lr.historyRetention > 0 {
+			if _, err := lr.pool.Exec(ctx, deleteExpiredHistoryQuery, now.Add(-lr.historyRetention)); err != nil && ctx.Err() == nil {
+				onError(fmt.Errorf("could not delete expired history: %w", err))
+			}
+		}
+	}
+}
+
+// minUpdatedAt returns the oldest update time allowed by maxAge and location ttl, nil if there is no limit
+func (lr *locationRepository) minUpdatedAt(maxAge time.Duration) *time.Time {
+	age := maxAge
+	if lr.locationTTL > 0 && (age == 0 || lr.locationTTL < age) {
+		age = lr.locationTTL
+	}
+	if age == 0 {
+		return nil
+	}
+	t := time.Now().Add(-age)
+	return &t
+}
+
+func statuses(filter domain.DriverFilter) []string {
+	statuses := make([]string, 0, len(filter.Statuses))
+	for _, s := range filter.Statuses {
+		statuses = append(statuses, string(s))
+	}
+	return statuses
+}
+
+func scanDriverLocation(row pgx.CollectableRow) (domain.DriverLocation, error) {
+	var l domain.DriverLocation
+	var lon, lat float64
+	var status string
+	var updatedAt time.Time
+	if err := row.Scan(&l.ID, &lon, &lat, &status, &updatedAt); err != nil {
+		return l, err
+	}
+	l.Status = domain.DriverStatus(status)
+	l.UpdatedAt = &updatedAt
+	l.Point = geojson.Point{
+		Type:        geojson.TypePoint,
+		Coordinates: geojson.Coordinate{lon, lat},
+	}
+	return l, nil
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockID serializes migrations of replicas starting at the same time
+const migrationLockID = 7283541
+
+type migration struct {
+	version    int
+	statements []string
+}
+
+// migrations are applied in order and must never be edited once released, add a new version instead
+var migrations = []migration{
+	{
+		version: 1,
+		statements: []string{
+			`CREATE EXTENSION IF NOT EXISTS postgis`,
+			`CREATE TABLE driver_location (
+				id uuid PRIMARY KEY,
+				location geography(Point, 4326) NOT NULL,
+				status text NOT NULL DEFAULT 'available',
+				updated_at timestamptz NOT NULL DEFAULT now()
+			)`,
+			`CREATE INDEX driver_location_location_idx ON driver_location USING GIST (location)`,
+			`CREATE INDEX driver_location_updated_at_idx ON driver_location (updated_at)`,
+			`CREATE TABLE driver_location_history (
+				driver_id uuid NOT NULL,
+				location geography(Point, 4326) NOT NULL,
+				recorded_at timestamptz NOT NULL
+			)`,
+			`CREATE INDEX driver_location_history_driver_id_recorded_at_idx ON driver_location_history (driver_id, recorded_at)`,
+		},
+	},
+	{
+		// driver ids are object ids like in mongodb, not uuids
+		version: 2,
+		statements: []string{
+			`ALTER TABLE driver_location ALTER COLUMN id TYPE text`,
+			`ALTER TABLE driver_location_history ALTER COLUMN driver_id TYPE text`,
+		},
+	},
+}
+
+func NewPool(ctx context.Context, connString string) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, connString)
+	if err != nil {
+		return nil, fmt.Errorf("could not create postgres pool: %w", err)
+	}
+	return pool, nil
+}
+
+// Migrate applies the migrations that are not recorded in schema_migrations table yet
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+			return fmt.Errorf("could not acquire migration lock: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version int PRIMARY KEY,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)`); err != nil {
+			return fmt.Errorf("could not create schema_migrations table: %w", err)
+		}
+
+		var current int
+		if err := tx.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+			return fmt.Errorf("could not read schema version: %w", err)
+		}
+
+		for _, m := range migrations {
+			if m.version <= current {
+				continue
+			}
+			for _, stmt := range m.statements {
+				if _, err := tx.Exec(ctx, stmt); err != nil {
+					return fmt.Errorf("could not apply migration %d: %w", m.version, err)
+				}
+			}
+			if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, m.version); err != nil {
+				return fmt.Errorf("could not record migration %d: %w", m.version, err)
+			}
+		}
+
+		return nil
+	})
+}
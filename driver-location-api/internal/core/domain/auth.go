@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Scopes
+const (
+	ScopeService = "service"
+	ScopeDriver  = "driver"
+)
+
+// Claims are the claims of the tokens accepted by the api
+type Claims struct {
+	jwt.RegisteredClaims
+	Scopes []string `json:"scopes,omitempty"`
+	// DriverID is the driver the token is issued to, set on tokens with driver scope
+	DriverID string `json:"driverId,omitempty"`
+}
+
+// Principal is the authenticated caller of the api
+type Principal struct {
+	Subject  string
+	Scopes   []string
+	DriverID string
+}
+
+// HasAnyScope reports whether the principal has at least one of the scopes
+func (p Principal) HasAnyScope(scopes ...string) bool {
+	for _, scope := range scopes {
+		if slices.Contains(p.Scopes, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanUpdateDriver reports whether the principal may update the location of the driver,
+// services may update any driver while drivers may only update themselves
+func (p Principal) CanUpdateDriver(id string) bool {
+	if p.HasAnyScope(ScopeService) {
+		return true
+	}
+	return p.HasAnyScope(ScopeDriver) && p.DriverID != "" && p.DriverID == id
+}
@@ -1,20 +1,66 @@
 package domain
 
 import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
-	"github.com/google/uuid"
 )
 
+// Driver Status Types
+const (
+	DriverStatusAvailable DriverStatus = "available"
+	DriverStatusOnTrip    DriverStatus = "on_trip"
+	DriverStatusOffline   DriverStatus = "offline"
+	DriverStatusBreak     DriverStatus = "break"
+)
+
+type DriverStatus string
+
+// driverIDLength is the length of driver ids, ids are 12 byte mongodb object ids in hex for every database
+const driverIDLength = 24
+
+// NewDriverID returns a new driver id, a timestamp followed by random bytes like a mongodb object id
+func NewDriverID() string {
+	var id [driverIDLength / 2]byte
+	binary.BigEndian.PutUint32(id[:4], uint32(time.Now().Unix()))
+	rand.Read(id[4:])
+	return hex.EncodeToString(id[:])
+}
+
+// ValidateDriverID checks that id is a driver id, the same ids are accepted whatever the database is
+func ValidateDriverID(id string) error {
+	if len(id) != driverIDLength {
+		return fmt.Errorf("id must be %d hex characters", driverIDLength)
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return fmt.Errorf("id must be hex: %w", err)
+	}
+	return nil
+}
+
+func (ds DriverStatus) IsValid() error {
+	switch ds {
+	case DriverStatusAvailable, DriverStatusOnTrip, DriverStatusOffline, DriverStatusBreak:
+		return nil
+	default:
+		return fmt.Errorf("unknown driver status: %s", ds)
+	}
+}
+
 type DriverLocation struct {
-	ID string `json:"id"`
+	ID        string       `json:"id"`
+	Status    DriverStatus `json:"status,omitempty"`
+	UpdatedAt *time.Time   `json:"updatedAt,omitempty"`
 	geojson.Point
 }
 
 func (dl DriverLocation) IsValid() error {
-	if err := uuid.Validate(dl.ID); err != nil {
+	if err := ValidateDriverID(dl.ID); err != nil {
 		return fmt.Errorf("invalid id: %w", err)
 	}
 	if dl.Point.IsValid() {
@@ -34,3 +80,23 @@ func (dl Distance) IsValid() error {
 	}
 	return nil
 }
+
+type DriverDistance struct {
+	Distance       Distance       `json:"distance"`
+	DriverLocation DriverLocation `json:"location"`
+}
+
+// DriverFilter narrows down the drivers returned by location queries
+type DriverFilter struct {
+	Statuses []DriverStatus
+	// MaxAge excludes locations that are not updated within the duration, zero means no limit
+	MaxAge time.Duration
+}
+
+// DriverLocationPage is a page of driver locations out of the total number of matching locations
+type DriverLocationPage struct {
+	Locations []DriverLocation `json:"locations"`
+	Total     int64            `json:"total"`
+	Offset    int              `json:"offset"`
+	Limit     int              `json:"limit"`
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/jwtauth"
+)
+
+type AuthService interface {
+	// Authenticate verifies a bearer token
+	Authenticate(ctx context.Context, token string) (*domain.Principal, error)
+	// AuthenticateCertificate maps a verified client certificate to an allowed service identity
+	AuthenticateCertificate(ctx context.Context, cert *x509.Certificate) (*domain.Principal, error)
+}
+
+type authService struct {
+	verifier          *jwtauth.Verifier
+	allowedIdentities map[string]struct{}
+}
+
+func NewAuthService(verifier *jwtauth.Verifier, allowedIdentities []string) *authService {
+	as := &authService{
+		verifier:          verifier,
+		allowedIdentities: make(map[string]struct{}, len(allowedIdentities)),
+	}
+	for _, identity := range allowedIdentities {
+		as.allowedIdentities[identity] = struct{}{}
+	}
+	return as
+}
+
+func (as *authService) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	if as.verifier == nil {
+		return nil, errs.ErrUnauthorized(errors.New("token authentication is not configured"))
+	}
+	claims := &domain.Claims{}
+	if _, err := as.verifier.Parse(token, claims); err != nil {
+		return nil, errs.ErrUnauthorized(err)
+	}
+	return &domain.Principal{
+		Subject:  claims.Subject,
+		Scopes:   claims.Scopes,
+		DriverID: claims.DriverID,
+	}, nil
+}
+
+func (as *authService) AuthenticateCertificate(ctx context.Context, cert *x509.Certificate) (*domain.Principal, error) {
+	identities := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	for _, identity := range identities {
+		if _, ok := as.allowedIdentities[identity]; ok && identity != "" {
+			return &domain.Principal{
+				Subject: identity,
+				Scopes:  []string{domain.ScopeService},
+			}, nil
+		}
+	}
+	return nil, errs.ErrUnauthorized(errors.New("client certificate identity is not allowed"))
+}
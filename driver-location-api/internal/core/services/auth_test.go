@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/jwtauth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret-of-at-least-32-bytes"
+
+func TestAuthenticate(t *testing.T) {
+	verifier, err := jwtauth.NewVerifier(jwtauth.Config{Algorithm: "HS256", Secret: testSecret, Audience: "driver-location-api"})
+	if err != nil {
+		t.Fatalf("could not create verifier: %v", err)
+	}
+	signer, err := jwtauth.NewSigner(jwtauth.SignerConfig{Algorithm: "HS256", Secret: testSecret})
+	if err != nil {
+		t.Fatalf("could not create signer: %v", err)
+	}
+	token, err := signer.Sign(domain.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "matching-api",
+			Audience:  jwt.ClaimStrings{"driver-location-api"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		Scopes: []string{domain.ScopeService},
+	})
+	if err != nil {
+		t.Fatalf("could not sign token: %v", err)
+	}
+
+	as := NewAuthService(verifier, nil)
+	principal, err := as.Authenticate(context.Background(), "Bearer "+token)
+	if err != nil {
+		t.Fatalf("expected token to be valid, got: %v", err)
+	}
+	if principal.Subject != "matching-api" || !principal.HasAnyScope(domain.ScopeService) {
+		t.Errorf("unexpected principal: %+v", principal)
+	}
+	if _, err := as.Authenticate(context.Background(), token+"x"); !errs.IsUnauthorizedErr(err) {
+		t.Errorf("expected unauthorized error, got: %v", err)
+	}
+}
+
+func TestAuthenticateCertificate(t *testing.T) {
+	as := NewAuthService(nil, []string{"matching-api", "matching.internal"})
+
+	testCases := []struct {
+		name     string
+		cert     *x509.Certificate
+		expected string
+	}{
+		{name: "should accept common name", cert: &x509.Certificate{Subject: pkix.Name{CommonName: "matching-api"}}, expected: "matching-api"},
+		{name: "should accept dns name", cert: &x509.Certificate{Subject: pkix.Name{CommonName: "other"}, DNSNames: []string{"matching.internal"}}, expected: "matching.internal"},
+		{name: "should reject unknown identity", cert: &x509.Certificate{Subject: pkix.Name{CommonName: "other"}}},
+		{name: "should reject empty identity", cert: &x509.Certificate{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			principal, err := as.AuthenticateCertificate(context.Background(), tc.cert)
+			if tc.expected == "" {
+				if !errs.IsUnauthorizedErr(err) {
+					t.Errorf("expected unauthorized error, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if principal.Subject != tc.expected || !principal.HasAnyScope(domain.ScopeService) {
+				t.Errorf("unexpected principal: %+v", principal)
+			}
+		})
+	}
+}
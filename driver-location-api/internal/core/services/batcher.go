@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"go.uber.org/zap"
+)
+
+type BatcherConfig struct {
+	// FlushInterval is the maximum time an update waits before it is written
+	FlushInterval time.Duration
+	// BatchSize triggers an early flush when this many drivers have pending updates
+	BatchSize int
+	// MaxPending blocks submitters while this many drivers have pending updates
+	MaxPending int
+	// MaxFlushRetries is how many consecutive failed flushes requeue their batch, the pending updates are
+	// dropped once it is exceeded
+	MaxFlushRetries int
+}
+
+// LocationBatcher coalesces streamed location updates by driver id and writes them
+// in micro batches, only the latest update of a driver within a batch is kept. A status
+// sent with an earlier update of the batch is kept if the latest update has none
+type LocationBatcher struct {
+	logger          *zap.Logger
+	locationService LocationService
+	cfg             BatcherConfig
+
+	mu      sync.Mutex
+	pending map[string]domain.DriverLocation
+	// flushed is closed and replaced after every flush to wake blocked submitters
+	flushed chan struct{}
+	flush   chan struct{}
+	// failedFlushes counts the consecutive failed flushes
+	failedFlushes int
+}
+
+func NewLocationBatcher(logger *zap.Logger, locationService LocationService, cfg BatcherConfig) *LocationBatcher {
+	return &LocationBatcher{
+		logger:          logger,
+		locationService: locationService,
+		cfg:             cfg,
+		pending:         make(map[string]domain.DriverLocation),
+		flushed:         make(chan struct{}),
+		flush:           make(chan struct{}, 1),
+	}
+}
+
+// Submit queues the location of a driver. It blocks while the batcher is full so that
+// a slow database slows down the connections instead of growing the queue unbounded.
+func (lb *LocationBatcher) Submit(ctx context.Context, location domain.DriverLocation) error {
+	for {
+		lb.mu.Lock()
+		if existing, ok := lb.pending[location.ID]; ok || len(lb.pending) < lb.cfg.MaxPending {
+			lb.pending[location.ID] = coalesce(existing, location)
+			full := len(lb.pending) >= lb.cfg.BatchSize
+			lb.mu.Unlock()
+			if full {
+				lb.requestFlush()
+			}
+			return nil
+		}
+		flushed := lb.flushed
+		lb.mu.Unlock()
+
+		lb.requestFlush()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-flushed:
+		}
+	}
+}
+
+// Run flushes pending updates periodically until ctx is done, remaining updates are flushed before returning
+func (lb *LocationBatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(lb.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx is already canceled, give the last batch its own deadline and retry it until it is written or dropped
+			flushCtx, cancel := context.WithTimeout(context.Background(), lb.cfg.FlushInterval+5*time.Second)
+			defer cancel()
+			for lb.flushPending(flushCtx) {
+			}
+			return nil
+		case <-ticker.C:
+		case <-lb.flush:
+		}
+		lb.flushPending(ctx)
+	}
+}
+
+func (lb *LocationBatcher) requestFlush() {
+	select {
+	case lb.flush <- struct{}{}:
+	default:
+	}
+}
+
+// flushPending writes the pending updates, it reports whether the batch failed and was requeued
+func (lb *LocationBatcher) flushPending(ctx context.Context) bool {
+	lb.mu.Lock()
+	if len(lb.pending) == 0 {
+		lb.mu.Unlock()
+		return false
+	}
+	batch := make([]domain.DriverLocation, 0, len(lb.pending))
+	for _, l := range lb.pending {
+		batch = append(batch, l)
+	}
+	lb.pending = make(map[string]domain.DriverLocation, lb.cfg.BatchSize)
+	lb.mu.Unlock()
+
+	// submitters are released only after the batch is written to keep them in step with the database
+	err := lb.locationService.CreateOrUpdateDriverLocations(ctx, batch)
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	requeued := false
+	switch {
+	case err == nil:
+		lb.failedFlushes = 0
+	case lb.failedFlushes < lb.cfg.MaxFlushRetries:
+		// requeue the batch under the updates received while it was written
+		lb.failedFlushes++
+		requeued = true
+		for _, l := range batch {
+			if newer, ok := lb.pending[l.ID]; ok {
+				lb.pending[l.ID] = coalesce(l, newer)
+			} else {
+				lb.pending[l.ID] = l
+			}
+		}
+		lb.logger.Warn("could not flush location batch, requeued it", zap.Error(err), zap.Int("size", len(batch)), zap.Int("failures", lb.failedFlushes))
+	default:
+		lb.failedFlushes = 0
+		lb.logger.Error("could not flush location batch, dropped it", zap.Error(err), zap.Int("size", len(batch)))
+	}
+	close(lb.flushed)
+	lb.flushed = make(chan struct{})
+	return requeued
+}
+
+// coalesce returns the newer update of a driver, the status of the older update is kept if the newer one has none
+func coalesce(older, newer domain.DriverLocation) domain.DriverLocation {
+	if newer.Status == "" {
+		newer.Status = older.Status
+	}
+	return newer
+}
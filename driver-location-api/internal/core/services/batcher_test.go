@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
+	"go.uber.org/zap"
+)
+
+// recordingLocationService records the written batches, other methods are not used by the batcher
+type recordingLocationService struct {
+	LocationService
+	mu      sync.Mutex
+	batches [][]domain.DriverLocation
+	// failures is the number of writes that fail before writes are recorded
+	failures int
+}
+
+func (rls *recordingLocationService) CreateOrUpdateDriverLocations(ctx context.Context, locations []domain.DriverLocation) error {
+	rls.mu.Lock()
+	defer rls.mu.Unlock()
+	if rls.failures > 0 {
+		rls.failures--
+		return errors.New("database unavailable")
+	}
+	rls.batches = append(rls.batches, locations)
+	return nil
+}
+
+func location(id string, lon, lat float64) domain.DriverLocation {
+	return domain.DriverLocation{
+		ID: id,
+		Point: geojson.Point{
+			Type:        geojson.TypePoint,
+			Coordinates: geojson.Coordinate{lon, lat},
+		},
+	}
+}
+
+func TestLocationBatcherCoalesces(t *testing.T) {
+	service := &recordingLocationService{}
+	batcher := NewLocationBatcher(zap.L(), service, BatcherConfig{
+		FlushInterval: time.Hour,
+		BatchSize:     10,
+		MaxPending:    10,
+	})
+
+	for _, l := range []domain.DriverLocation{
+		location("a", 29.0, 41.0),
+		location("b", 30.0, 40.0),
+		location("a", 29.1, 41.1),
+		location("a", 29.2, 41.2),
+	} {
+		if err := batcher.Submit(context.Background(), l); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// canceled context flushes the remaining updates
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := batcher.Run(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(service.batches) != 1 || len(service.batches[0]) != 2 {
+		t.Fatalf("expected a single batch of 2 locations, got: %+v", service.batches)
+	}
+	for _, l := range service.batches[0] {
+		if l.ID == "a" && l.Coordinates[0] != 29.2 {
+			t.Errorf("expected latest location of a, got: %v", l.Coordinates)
+		}
+	}
+}
+
+func TestLocationBatcherBackpressure(t *testing.T) {
+	service := &recordingLocationService{}
+	batcher := NewLocationBatcher(zap.L(), service, BatcherConfig{
+		FlushInterval: time.Hour,
+		BatchSize:     10,
+		MaxPending:    1,
+	})
+
+	if err := batcher.Submit(context.Background(), location("a", 29.0, 41.0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// updates of a pending driver are coalesced and never block
+	if err := batcher.Submit(context.Background(), location("a", 29.1, 41.1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// batcher is full and nothing flushes it
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := batcher.Submit(ctx, location("b", 30.0, 40.0)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected submit to block until deadline, got: %v", err)
+	}
+
+	// blocked submit requests a flush and continues once it is done
+	runCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go batcher.Run(runCtx)
+	if err := batcher.Submit(context.Background(), location("b", 30.0, 40.0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	if len(service.batches) == 0 || service.batches[0][0].ID != "a" {
+		t.Errorf("expected pending location of a to be flushed first, got: %+v", service.batches)
+	}
+}
+
+func TestLocationBatcherKeepsStatus(t *testing.T) {
+	service := &recordingLocationService{}
+	batcher := NewLocationBatcher(zap.L(), service, BatcherConfig{
+		FlushInterval: time.Hour,
+		BatchSize:     10,
+		MaxPending:    10,
+	})
+
+	busy := location("a", 29.0, 41.0)
+	busy.Status = domain.DriverStatusOnTrip
+	for _, l := range []domain.DriverLocation{busy, location("a", 29.1, 41.1)} {
+		if err := batcher.Submit(context.Background(), l); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := batcher.Run(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(service.batches) != 1 || len(service.batches[0]) != 1 {
+		t.Fatalf("expected a single batch of 1 location, got: %+v", service.batches)
+	}
+	if l := service.batches[0][0]; l.Status != domain.DriverStatusOnTrip || l.Coordinates[0] != 29.1 {
+		t.Errorf("expected latest location of a with status of earlier update, got: %+v", l)
+	}
+}
+
+func TestLocationBatcherRequeuesFailedBatch(t *testing.T) {
+	t.Run("should write requeued batch with newer updates", func(t *testing.T) {
+		service := &recordingLocationService{failures: 1}
+		batcher := NewLocationBatcher(zap.L(), service, BatcherConfig{
+			FlushInterval:   time.Hour,
+			BatchSize:       10,
+			MaxPending:      10,
+			MaxFlushRetries: 2,
+		})
+
+		busy := location("a", 29.0, 41.0)
+		busy.Status = domain.DriverStatusOnTrip
+		for _, l := range []domain.DriverLocation{busy, location("b", 30.0, 40.0)} {
+			if err := batcher.Submit(context.Background(), l); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if !batcher.flushPending(context.Background()) {
+			t.Fatal("expected failed batch to be requeued")
+		}
+
+		// update received while the failed batch was written
+		if err := batcher.Submit(context.Background(), location("a", 29.1, 41.1)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if batcher.flushPending(context.Background()) {
+			t.Fatal("expected batch to be written")
+		}
+
+		if len(service.batches) != 1 || len(service.batches[0]) != 2 {
+			t.Fatalf("expected a single batch of 2 locations, got: %+v", service.batches)
+		}
+		for _, l := range service.batches[0] {
+			if l.ID == "a" && (l.Status != domain.DriverStatusOnTrip || l.Coordinates[0] != 29.1) {
+				t.Errorf("expected latest location of a with requeued status, got: %+v", l)
+			}
+		}
+	})
+
+	t.Run("should drop batch once retries are exhausted", func(t *testing.T) {
+		service := &recordingLocationService{failures: 3}
+		batcher := NewLocationBatcher(zap.L(), service, BatcherConfig{
+			FlushInterval:   time.Hour,
+			BatchSize:       10,
+			MaxPending:      10,
+			MaxFlushRetries: 2,
+		})
+
+		if err := batcher.Submit(context.Background(), location("a", 29.0, 41.0)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// canceled context retries the remaining updates until they are dropped
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := batcher.Run(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if service.failures != 0 || len(service.batches) != 0 {
+			t.Errorf("expected batch to be tried 3 times and dropped, got %d failures left and batches: %+v", service.failures, service.batches)
+		}
+		if len(batcher.pending) != 0 {
+			t.Errorf("expected no pending updates, got: %+v", batcher.pending)
+		}
+	})
+}
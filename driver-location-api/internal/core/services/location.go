@@ -4,17 +4,23 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/adapters/importer"
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/adapters/repositories"
 	"github.com/aniladanir/bitaksi-casestudy/driver-location-api/internal/core/domain"
 	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
 	"github.com/aniladanir/bitaksi-casestudy/shared/haversine"
 )
 
 type LocationService interface {
 	CreateOrUpdateDriverLocations(ctx context.Context, locations []domain.DriverLocation) error
-	FindNearestDriverDistance(ctx context.Context, location domain.DriverLocation, searchRadius float64) (*domain.DriverLocation, *domain.Distance, error)
+	UpdateDriverStatus(ctx context.Context, id string, status domain.DriverStatus) error
+	FindNearestDriverDistance(ctx context.Context, location domain.DriverLocation, searchRadius float64, filter domain.DriverFilter) (*domain.DriverLocation, *domain.Distance, error)
+	FindNearestDriverDistances(ctx context.Context, location domain.DriverLocation, searchRadius float64, limit int, filter domain.DriverFilter) ([]domain.DriverDistance, error)
+	FindDriversWithin(ctx context.Context, area geojson.Geometry, offset, limit int, filter domain.DriverFilter) (*domain.DriverLocationPage, error)
+	GetDriverTrail(ctx context.Context, id string, from, to time.Time) (*geojson.LineString, error)
 	ImportLocation(ctx context.Context, reader io.Reader) error
 	IsValidID(id string) error
 }
@@ -36,11 +42,18 @@ func (ls *locationService) CreateOrUpdateDriverLocations(ctx context.Context, lo
 }
 
 func (ls *locationService) IsValidID(id string) error {
-	return ls.locationRepo.IsValidID(id)
+	if err := domain.ValidateDriverID(id); err != nil {
+		return errs.ErrInternal(fmt.Errorf("invalid id: %w", err))
+	}
+	return nil
+}
+
+func (ls *locationService) UpdateDriverStatus(ctx context.Context, id string, status domain.DriverStatus) error {
+	return ls.locationRepo.UpdateStatus(ctx, id, status)
 }
 
-func (ls *locationService) FindNearestDriverDistance(ctx context.Context, userLocation domain.DriverLocation, searchRadius float64) (*domain.DriverLocation, *domain.Distance, error) {
-	driverLocation, err := ls.locationRepo.GetNearestDriverLocation(ctx, userLocation, searchRadius)
+func (ls *locationService) FindNearestDriverDistance(ctx context.Context, userLocation domain.DriverLocation, searchRadius float64, filter domain.DriverFilter) (*domain.DriverLocation, *domain.Distance, error) {
+	driverLocation, err := ls.locationRepo.GetNearestDriverLocation(ctx, userLocation, searchRadius, withDefaultStatuses(filter))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -56,6 +69,73 @@ func (ls *locationService) FindNearestDriverDistance(ctx context.Context, userLo
 	}, nil
 }
 
+func (ls *locationService) FindNearestDriverDistances(ctx context.Context, userLocation domain.DriverLocation, searchRadius float64, limit int, filter domain.DriverFilter) ([]domain.DriverDistance, error) {
+	driverLocations, err := ls.locationRepo.GetNearestDriverLocations(ctx, userLocation, searchRadius, limit, withDefaultStatuses(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	distances := make([]domain.DriverDistance, 0, len(driverLocations))
+	for _, driverLocation := range driverLocations {
+		distanceKM, err := haversine.HaversineDistanceInKM(driverLocation.Point, userLocation.Point)
+		if err != nil {
+			return nil, errs.ErrInternal(fmt.Errorf("could not calculate distance between points: %w", err))
+		}
+		distances = append(distances, domain.DriverDistance{
+			Distance: domain.Distance{
+				Distance: distanceKM,
+				Unit:     "km",
+			},
+			DriverLocation: driverLocation,
+		})
+	}
+
+	return distances, nil
+}
+
+func (ls *locationService) FindDriversWithin(ctx context.Context, area geojson.Geometry, offset, limit int, filter domain.DriverFilter) (*domain.DriverLocationPage, error) {
+	locations, total, err := ls.locationRepo.GetDriverLocationsWithin(ctx, area, offset, limit, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.DriverLocationPage{
+		Locations: locations,
+		Total:     total,
+		Offset:    offset,
+		Limit:     limit,
+	}, nil
+}
+
+func (ls *locationService) GetDriverTrail(ctx context.Context, id string, from, to time.Time) (*geojson.LineString, error) {
+	history, err := ls.locationRepo.GetLocationHistory(ctx, id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	// a line string needs at least two positions
+	if len(history) < 2 {
+		return nil, errs.ErrEntityNotFound("driver trail")
+	}
+
+	trail := &geojson.LineString{
+		Type:        geojson.TypeLineString,
+		Coordinates: make(geojson.Coordinates, 0, len(history)),
+	}
+	for _, location := range history {
+		trail.Coordinates = append(trail.Coordinates, location.Coordinates)
+	}
+
+	return trail, nil
+}
+
 func (ls *locationService) ImportLocation(ctx context.Context, reader io.Reader) error {
 	return ls.locationImporter.ImportCoordinates(ctx, reader)
 }
+
+// withDefaultStatuses narrows the filter down to available drivers if no status is requested
+func withDefaultStatuses(filter domain.DriverFilter) domain.DriverFilter {
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []domain.DriverStatus{domain.DriverStatusAvailable}
+	}
+	return filter
+}
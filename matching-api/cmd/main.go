@@ -2,20 +2,30 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/adapters/authstore"
 	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/adapters/handlers/httphandler"
 	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/adapters/locationfinder"
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/domain"
 	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/services"
 	"github.com/aniladanir/bitaksi-casestudy/shared/circuitbreaker"
 	"github.com/aniladanir/bitaksi-casestudy/shared/config"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
+	"github.com/aniladanir/bitaksi-casestudy/shared/jwtauth"
 	"github.com/aniladanir/bitaksi-casestudy/shared/log"
+	"github.com/aniladanir/bitaksi-casestudy/shared/retry"
+	"github.com/aniladanir/bitaksi-casestudy/shared/tlsconfig"
+	"github.com/aniladanir/bitaksi-casestudy/shared/tracing"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -25,13 +35,25 @@ var configFile = flag.String("config", "./config.yaml", "provide configuration f
 func main() {
 	flag.Parse()
 
-	httpHandler, err := InitializeComponents()
+	ctx, cancel := context.WithCancel(context.Background())
+	errGroup, errGroupCtx := errgroup.WithContext(ctx)
+
+	httpHandler, err := InitializeComponents(ctx)
 	if err != nil {
 		log.Fatal("encountered error when initializing components", zap.Error(err))
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	errGroup, errGroupCtx := errgroup.WithContext(ctx)
+	// export traces, the w3c trace context is propagated even if tracing is disabled
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		Enabled:     config.IsTracingEnabled(),
+		ServiceName: "matching-api",
+		Endpoint:    config.GetTracingEndpoint(),
+		Insecure:    config.IsTracingInsecure(),
+		SampleRatio: config.GetTracingSampleRatio(),
+	})
+	if err != nil {
+		log.Fatal("could not initialize tracing", zap.Error(err))
+	}
 
 	// listen os signals and cancel the parent context if one is received.
 	go ListenOsSignal(cancel)
@@ -42,6 +64,13 @@ func main() {
 		return fmt.Errorf("http handler failed listening: %v", err)
 	})
 
+	// start metrics server, it listens apart from the api so that metrics are not exposed publicly
+	metricsServer := httpfiber.NewMetricsServer()
+	errGroup.Go(func() error {
+		err := metricsServer.Listen(config.GetMetricsServerAddress())
+		return fmt.Errorf("metrics server failed listening: %v", err)
+	})
+
 	// gracefully shutdown application if context is canceled
 	errGroup.Go(func() error {
 		<-errGroupCtx.Done()
@@ -52,6 +81,18 @@ func main() {
 			return err
 		}
 
+		// Shut down metrics server
+		if err := metricsServer.Shutdown(); err != nil {
+			return err
+		}
+
+		// flush pending spans
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelShutdown()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			return fmt.Errorf("could not shutdown tracing: %w", err)
+		}
+
 		return nil
 	})
 
@@ -63,8 +104,8 @@ func main() {
 	log.Info("greceful shutdown is complete")
 }
 
-// InitializeComponents initalizes all adapters needed by application
-func InitializeComponents() (*httphandler.Handler, error) {
+// InitializeComponents initalizes all adapters needed by application, background work stops once ctx is done
+func InitializeComponents(ctx context.Context) (*httphandler.Handler, error) {
 	config.Init(*configFile)
 
 	// configure logrotate options
@@ -75,41 +116,282 @@ func InitializeComponents() (*httphandler.Handler, error) {
 		GzipArchive: config.GetLogGzipArchive(),
 	}
 
-	// create circuit breaker
-	cb := circuitbreaker.NewCircuitBreaker(
-		circuitbreaker.WithMaxFailures(config.GetCircuitBreakerMaxFailures()),
-		circuitbreaker.WithRetryTimeout(time.Duration(config.GetCircuitBreakerRetryTimeout())*time.Second),
-	)
+	// create app logger
+	debug := config.IsDebug()
+	appLogger := log.NewLoggerWithLogRotate(debug, config.GetLogFile(), logRotateCfg)
+
+	// create circuit breaker options
+	cbOpts, err := CircuitBreakerOptions(appLogger.With(zap.String("component", "circuitbreaker")))
+	if err != nil {
+		return nil, err
+	}
 
 	// create driver location api client
-	driverLocationApiUrl, err := url.Parse(config.GetRemoteUrl("driverLocationApi"))
+	locationFinder, err := NewLocationFinder(ctx, cbOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// create jwt verifier
+	verifier, err := jwtauth.NewVerifier(jwtauth.Config{
+		Algorithm:           config.GetAuthJwtAlgorithm(),
+		Secret:              config.GetAuthJwtSecret(),
+		SecretFile:          config.GetAuthJwtSecretFile(),
+		PublicKeyFile:       config.GetAuthJwtPublicKeyFile(),
+		JWKSURL:             config.GetAuthJwtJWKSUrl(),
+		JWKSRefreshInterval: time.Duration(config.GetAuthJwtJWKSRefreshInterval()) * time.Second,
+		JWKSFile:            config.GetAuthJwtJWKSFile(),
+		Issuer:              config.GetAuthJwtIssuer(),
+		Audience:            config.GetAuthJwtAudience(),
+		Leeway:              time.Duration(config.GetAuthJwtLeeway()) * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create jwt verifier: %w", err)
+	}
+
+	// create jwt signer
+	signer, err := jwtauth.NewSigner(jwtauth.SignerConfig{
+		Algorithm:      config.GetAuthJwtAlgorithm(),
+		Secret:         config.GetAuthJwtSecret(),
+		SecretFile:     config.GetAuthJwtSecretFile(),
+		PrivateKeyFile: config.GetAuthJwtPrivateKeyFile(),
+		KeyID:          config.GetAuthJwtKeyID(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create jwt signer: %w", err)
+	}
+
+	// create client store from configured clients
+	authClients, err := config.GetAuthClients()
 	if err != nil {
-		return nil, fmt.Errorf("could not parse driver location api url: %w", err)
+		return nil, fmt.Errorf("could not read auth clients: %w", err)
+	}
+	clients := make([]domain.Client, 0, len(authClients))
+	for _, c := range authClients {
+		clients = append(clients, domain.Client{ID: c.ID, SecretHash: c.SecretHash, Scopes: c.Scopes})
 	}
-	driverLocationApiClient := locationfinder.NewDriverLocationApiClient(
-		*driverLocationApiUrl,
-		config.GetRemoteVersion("driverLocationApi"),
-		time.Duration(config.GetHttpClientTimeout())*time.Second,
-		cb,
+
+	// create auth service
+	authService := services.NewAuthService(
+		authstore.NewStaticClientStore(clients),
+		authstore.NewMemoryRevocationStore(),
+		signer,
+		verifier,
+		services.TokenConfig{
+			Issuer:          config.GetAuthJwtIssuer(),
+			Audience:        config.GetAuthJwtAudience(),
+			AccessTokenTTL:  time.Duration(config.GetAuthAccessTokenTTL()) * time.Second,
+			RefreshTokenTTL: time.Duration(config.GetAuthRefreshTokenTTL()) * time.Second,
+		},
 	)
 
-	// create http handler
-	debug := config.IsDebug()
+	// create rate limiter
+	rateLimiter, err := httpfiber.NewRateLimiterFromConfig(appLogger.With(zap.String("middleware", "ratelimit")))
+	if err != nil {
+		return nil, err
+	}
+
 	httpHandler := httphandler.NewHandler(
 		httphandler.ServerConfig{
 			WriteTimeout: time.Duration(config.GetHttpWriteTimeout()) * time.Second,
 			ReadTimeout:  time.Duration(config.GetHttpReadTimeout()) * time.Second,
 			IdleTimeout:  time.Duration(config.GetHttpIdleTimeout()) * time.Second,
 		},
-		log.NewLoggerWithLogRotate(debug, config.GetLogFile(), logRotateCfg),
+		httphandler.HealthConfig{
+			ReadinessTimeout: time.Duration(config.GetHealthReadinessTimeout()) * time.Second,
+			ReadinessChecks: map[string]httpfiber.HealthCheck{
+				"driver-location-api": locationFinder.Ping,
+			},
+		},
+		appLogger,
 		log.NewLoggerWithLogRotate(debug, config.GetAccessLogFile(), logRotateCfg),
-		services.NewDriverService(driverLocationApiClient),
+		services.NewDriverService(locationFinder),
+		authService,
+		rateLimiter,
 		config.GetAPIVersion(),
 	)
 
 	return httpHandler, nil
 }
 
+// CircuitBreakerOptions returns the options of the driver location api circuit breakers of the configured window,
+// no driver nearby results are not failures of the driver location api
+func CircuitBreakerOptions(logger *zap.Logger) ([]circuitbreaker.CircuitBreakerOption, error) {
+	opts := []circuitbreaker.CircuitBreakerOption{
+		circuitbreaker.WithRetryTimeout(time.Duration(config.GetCircuitBreakerRetryTimeout()) * time.Second),
+		circuitbreaker.WithHalfOpenMaxRequests(config.GetCircuitBreakerHalfOpenMaxRequests()),
+		circuitbreaker.WithIsFailure(func(err error) bool {
+			return !errs.IsEntityNotFoundErr(err)
+		}),
+		circuitbreaker.WithOnStateChange(func(name string, from, to circuitbreaker.CircuitBreakerState) {
+			logger.Warn("circuit breaker state changed",
+				zap.String("name", name),
+				zap.String("from", from.String()),
+				zap.String("to", to.String()),
+			)
+		}),
+	}
+
+	window := config.GetCircuitBreakerWindow()
+	failureRate := config.GetCircuitBreakerFailureRate()
+	if (window == "count" || window == "time") && (failureRate <= 0 || failureRate > 1) {
+		return nil, fmt.Errorf("circuit breaker failure rate must be within (0, 1], got: %v", failureRate)
+	}
+
+	switch window {
+	case "consecutive", "":
+		opts = append(opts, circuitbreaker.WithMaxFailures(config.GetCircuitBreakerMaxFailures()))
+	case "count":
+		windowSize := config.GetCircuitBreakerWindowSize()
+		if windowSize <= 0 {
+			return nil, fmt.Errorf("circuit breaker window size must be positive, got: %d", windowSize)
+		}
+		opts = append(opts, circuitbreaker.WithCountWindow(
+			windowSize,
+			failureRate,
+			config.GetCircuitBreakerMinimumRequests(),
+		))
+	case "time":
+		windowDuration := config.GetCircuitBreakerWindowDuration()
+		if windowDuration <= 0 {
+			return nil, fmt.Errorf("circuit breaker window duration must be positive, got: %d", windowDuration)
+		}
+		opts = append(opts, circuitbreaker.WithTimeWindow(
+			time.Duration(windowDuration)*time.Second,
+			failureRate,
+			config.GetCircuitBreakerMinimumRequests(),
+		))
+	default:
+		return nil, fmt.Errorf("unknown circuit breaker window: %s", window)
+	}
+
+	return opts, nil
+}
+
+// NewLocationFinder creates the driver location api client of the configured protocol, the http client
+// keeps a circuit breaker per replica and health checks the replicas until ctx is done, the grpc client
+// has a single circuit breaker for all replicas
+func NewLocationFinder(ctx context.Context, cbOpts []circuitbreaker.CircuitBreakerOption) (locationfinder.LocationFinder, error) {
+	timeout := time.Duration(config.GetHttpClientTimeout()) * time.Second
+
+	// credentials attached to every request
+	tlsConfig, err := tlsconfig.Client(tlsconfig.Config{
+		CertFile: config.GetRemoteTLSCertFile("driverLocationApi"),
+		KeyFile:  config.GetRemoteTLSKeyFile("driverLocationApi"),
+		CAFile:   config.GetRemoteTLSCAFile("driverLocationApi"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create driver location api tls config: %w", err)
+	}
+	token, err := RemoteToken("driverLocationApi")
+	if err != nil {
+		return nil, err
+	}
+	// driver-location-api accepts service tokens or client certificates only
+	if token == "" && config.GetRemoteTLSCertFile("driverLocationApi") == "" {
+		return nil, errors.New("driver location api requires a service token or a client certificate, " +
+			"set remote.driverLocationApi.token, remote.driverLocationApi.tokenFile or remote.driverLocationApi.tls.certFile")
+	}
+	creds := locationfinder.Credentials{
+		Token:     token,
+		TLSConfig: tlsConfig,
+	}
+
+	switch protocol := config.GetRemoteProtocol("driverLocationApi"); protocol {
+	case "grpc":
+		return locationfinder.NewDriverLocationGrpcClient(
+			config.GetRemoteGrpcAddress("driverLocationApi"),
+			timeout,
+			circuitbreaker.NewCircuitBreaker(append([]circuitbreaker.CircuitBreakerOption{circuitbreaker.WithName("driver-location-api")}, cbOpts...)...),
+			creds,
+		)
+	case "http", "":
+		balancerCfg, err := NewBalancerConfig(cbOpts)
+		if err != nil {
+			return nil, err
+		}
+		client := locationfinder.NewDriverLocationApiClient(
+			balancerCfg,
+			locationfinder.HedgingConfig{
+				Enabled:    config.IsRemoteHedgingEnabled("driverLocationApi"),
+				Percentile: config.GetRemoteHedgingPercentile("driverLocationApi"),
+				MinDelay:   time.Duration(config.GetRemoteHedgingMinDelayInMs("driverLocationApi")) * time.Millisecond,
+				MaxDelay:   time.Duration(config.GetRemoteHedgingMaxDelayInMs("driverLocationApi")) * time.Millisecond,
+			},
+			config.GetRemoteVersion("driverLocationApi"),
+			timeout,
+			retry.Policy{
+				MaxAttempts:    config.GetRemoteRetryMaxAttempts("driverLocationApi"),
+				InitialBackoff: time.Duration(config.GetRemoteRetryInitialBackoffInMs("driverLocationApi")) * time.Millisecond,
+				MaxBackoff:     time.Duration(config.GetRemoteRetryMaxBackoffInMs("driverLocationApi")) * time.Millisecond,
+				Multiplier:     config.GetRemoteRetryMultiplier("driverLocationApi"),
+				Jitter:         config.GetRemoteRetryJitter("driverLocationApi"),
+			},
+			creds,
+		)
+		go client.Run(ctx)
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown driver location api protocol: %s", protocol)
+	}
+}
+
+// RemoteToken returns the bearer token of the service, it is read from the token file if no token is set
+func RemoteToken(serviceName string) (string, error) {
+	if token := config.GetRemoteToken(serviceName); token != "" {
+		return token, nil
+	}
+	tokenFile := config.GetRemoteTokenFile(serviceName)
+	if tokenFile == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s token file: %w", serviceName, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// NewBalancerConfig returns the replicas of the driver location api, urls falls back to url if it is empty
+// and srv replaces both. The scheme of srv replicas is the scheme of url
+func NewBalancerConfig(cbOpts []circuitbreaker.CircuitBreakerOption) (locationfinder.BalancerConfig, error) {
+	rawUrls := config.GetRemoteUrls("driverLocationApi")
+	if len(rawUrls) == 0 {
+		rawUrls = []string{config.GetRemoteUrl("driverLocationApi")}
+	}
+	urls := make([]url.URL, 0, len(rawUrls))
+	for _, rawUrl := range rawUrls {
+		u, err := url.Parse(rawUrl)
+		if err != nil {
+			return locationfinder.BalancerConfig{}, fmt.Errorf("could not parse driver location api url: %w", err)
+		}
+		urls = append(urls, *u)
+	}
+
+	cfg := locationfinder.BalancerConfig{
+		Strategy:            config.GetRemoteBalancerStrategy("driverLocationApi"),
+		URLs:                urls,
+		SRVName:             config.GetRemoteSRV("driverLocationApi"),
+		Scheme:              urls[0].Scheme,
+		ResolveInterval:     time.Duration(config.GetRemoteResolveInterval("driverLocationApi")) * time.Second,
+		HealthCheckInterval: time.Duration(config.GetRemoteHealthCheckInterval("driverLocationApi")) * time.Second,
+		NewCircuitBreaker: func(name string) *circuitbreaker.CircuitBreaker {
+			return circuitbreaker.NewCircuitBreaker(append([]circuitbreaker.CircuitBreakerOption{
+				circuitbreaker.WithName("driver-location-api/" + name),
+			}, cbOpts...)...)
+		},
+	}
+	switch cfg.Strategy {
+	case locationfinder.StrategyRoundRobin, locationfinder.StrategyLeastOutstanding, "":
+	default:
+		return locationfinder.BalancerConfig{}, fmt.Errorf("unknown driver location api balancer strategy: %s", cfg.Strategy)
+	}
+	if cfg.SRVName != "" {
+		cfg.URLs = nil
+	}
+	return cfg, nil
+}
+
 func ListenOsSignal(onSignal func()) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
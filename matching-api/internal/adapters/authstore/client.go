@@ -0,0 +1,35 @@
+package authstore
+
+import (
+	"context"
+
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+)
+
+type ClientStore interface {
+	GetClient(ctx context.Context, id string) (*domain.Client, error)
+}
+
+// staticClientStore keeps the clients given on startup, e.g. from configuration
+type staticClientStore struct {
+	clients map[string]domain.Client
+}
+
+func NewStaticClientStore(clients []domain.Client) *staticClientStore {
+	store := &staticClientStore{
+		clients: make(map[string]domain.Client, len(clients)),
+	}
+	for _, c := range clients {
+		store.clients[c.ID] = c
+	}
+	return store
+}
+
+func (s *staticClientStore) GetClient(ctx context.Context, id string) (*domain.Client, error) {
+	client, ok := s.clients[id]
+	if !ok {
+		return nil, errs.ErrEntityNotFound("client")
+	}
+	return &client, nil
+}
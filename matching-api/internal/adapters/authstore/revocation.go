@@ -0,0 +1,53 @@
+package authstore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type RevocationStore interface {
+	// Revoke rejects the token id until expiresAt, when the token expires anyway. It reports false if the id
+	// was already revoked, checking and revoking is atomic so that concurrent revokes of an id revoke it once
+	Revoke(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+	IsRevoked(ctx context.Context, id string) (bool, error)
+}
+
+// memoryRevocationStore keeps revoked token ids in memory, revocations are not shared between replicas
+type memoryRevocationStore struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+func NewMemoryRevocationStore() *memoryRevocationStore {
+	return &memoryRevocationStore{
+		revoked: make(map[string]time.Time),
+	}
+}
+
+func (s *memoryRevocationStore) Revoke(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// drop expired revocations while holding the write lock
+	now := time.Now()
+	for revokedID, exp := range s.revoked {
+		if now.After(exp) {
+			delete(s.revoked, revokedID)
+		}
+	}
+	if _, ok := s.revoked[id]; ok {
+		return false, nil
+	}
+	s.revoked[id] = expiresAt
+
+	return true, nil
+}
+
+func (s *memoryRevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.revoked[id]
+	return ok, nil
+}
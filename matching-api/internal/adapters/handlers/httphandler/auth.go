@@ -1,26 +1,31 @@
 package httphandler
 
 import (
+	"encoding/json"
 	"net/http"
 
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/services"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
 	"github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
 	"github.com/aniladanir/bitaksi-casestudy/shared/response"
 	"github.com/gofiber/fiber/v3"
-	"github.com/golang-jwt/jwt"
 	"go.uber.org/zap"
 )
 
 type authHandler struct {
-	logger *zap.Logger
+	logger      *zap.Logger
+	authService services.AuthService
 }
 
-func newAuthHandler(logger *zap.Logger) *authHandler {
+func newAuthHandler(logger *zap.Logger, authService services.AuthService) *authHandler {
 	return &authHandler{
-		logger: logger,
+		logger:      logger,
+		authService: authService,
 	}
 }
 
-func (ah *authHandler) Authenticate(middleware bool) fiber.Handler {
+// Authenticate validates the access token, if scopes are given the token must carry at least one of them
+func (ah *authHandler) Authenticate(middleware bool, scopes ...string) fiber.Handler {
 	return func(ctx fiber.Ctx) error {
 		logger := httpfiber.CtxLogger(ctx, ah.logger)
 		tokenStr := ctx.Get(fiber.HeaderAuthorization)
@@ -28,19 +33,101 @@ func (ah *authHandler) Authenticate(middleware bool) fiber.Handler {
 			logger.Error("missing authorization header")
 			return response.Fail(ctx, response.ErrCodeUnauthorized, "missing authorization header", http.StatusUnauthorized)
 		}
-		token, _, err := new(jwt.Parser).ParseUnverified(tokenStr, &JwtClaims{})
+		claims, err := ah.authService.Authenticate(ctx.UserContext(), tokenStr)
 		if err != nil {
-			logger.Error("token is in unknown format", zap.Error(err))
-			return response.Fail(ctx, response.ErrCodeUnauthorized, "token is in unknown format", http.StatusUnauthorized)
-		}
-
-		if err = token.Claims.Valid(); err != nil {
 			logger.Error("token validation failed", zap.Error(err))
-			return response.Fail(ctx, response.ErrCodeUnauthorized, err.Error(), http.StatusUnauthorized)
+			return ah.fail(ctx, err)
+		}
+		if len(scopes) > 0 && !claims.HasAnyScope(scopes...) {
+			logger.Error("token does not have required scopes", zap.Strings("required", scopes), zap.Strings("scopes", claims.Scopes))
+			return response.Fail(ctx, response.ErrCodeForbidden, response.ErrMsgForbidden, http.StatusForbidden)
 		}
+		ctx.Locals(httpfiber.CtxKeySubject, claims.Subject)
 		if middleware {
 			return ctx.Next()
 		}
 		return response.Success(ctx, nil)
 	}
 }
+
+func (ah *authHandler) IssueToken(ctx fiber.Ctx) error {
+	type RequestPayload struct {
+		ClientID     string `json:"clientId"`
+		ClientSecret string `json:"clientSecret"`
+	}
+
+	// get context logger
+	logger := httpfiber.CtxLogger(ctx, ah.logger)
+
+	// parse body
+	var payload RequestPayload
+	if err := json.Unmarshal(ctx.Body(), &payload); err != nil || payload.ClientID == "" || payload.ClientSecret == "" {
+		logger.Error("invalid token request payload", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
+	}
+
+	// call auth service
+	tokenPair, err := ah.authService.IssueToken(ctx.UserContext(), payload.ClientID, payload.ClientSecret)
+	if err != nil {
+		logger.Error("could not issue token", zap.Error(err), zap.String("clientId", payload.ClientID))
+		return ah.fail(ctx, err)
+	}
+
+	return response.Success(ctx, tokenPair)
+}
+
+func (ah *authHandler) RefreshToken(ctx fiber.Ctx) error {
+	type RequestPayload struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+
+	// get context logger
+	logger := httpfiber.CtxLogger(ctx, ah.logger)
+
+	// parse body
+	var payload RequestPayload
+	if err := json.Unmarshal(ctx.Body(), &payload); err != nil || payload.RefreshToken == "" {
+		logger.Error("invalid refresh request payload", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
+	}
+
+	// call auth service
+	tokenPair, err := ah.authService.RefreshToken(ctx.UserContext(), payload.RefreshToken)
+	if err != nil {
+		logger.Error("could not refresh token", zap.Error(err))
+		return ah.fail(ctx, err)
+	}
+
+	return response.Success(ctx, tokenPair)
+}
+
+func (ah *authHandler) RevokeToken(ctx fiber.Ctx) error {
+	type RequestPayload struct {
+		Token string `json:"token"`
+	}
+
+	// get context logger
+	logger := httpfiber.CtxLogger(ctx, ah.logger)
+
+	// parse body
+	var payload RequestPayload
+	if err := json.Unmarshal(ctx.Body(), &payload); err != nil || payload.Token == "" {
+		logger.Error("invalid revoke request payload", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
+	}
+
+	// call auth service
+	if err := ah.authService.RevokeToken(ctx.UserContext(), payload.Token); err != nil {
+		logger.Error("could not revoke token", zap.Error(err))
+		return ah.fail(ctx, err)
+	}
+
+	return response.Success(ctx, nil)
+}
+
+func (ah *authHandler) fail(ctx fiber.Ctx, err error) error {
+	if errs.IsUnauthorizedErr(err) {
+		return response.Fail(ctx, response.ErrCodeUnauthorized, response.ErrMsgUnauthorized, http.StatusUnauthorized)
+	}
+	return response.Fail(ctx, response.ErrCodeInternal, response.ErrMsgInternal, http.StatusInternalServerError)
+}
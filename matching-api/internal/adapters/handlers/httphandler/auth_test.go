@@ -0,0 +1,98 @@
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/services"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/response"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// MockAuthService maps tokens to the scopes of the caller
+type MockAuthService struct {
+	services.AuthService
+}
+
+func (*MockAuthService) Authenticate(ctx context.Context, accessToken string) (*domain.JwtClaims, error) {
+	switch accessToken {
+	case "Bearer rider-token":
+		return &domain.JwtClaims{Authenticated: true, Scopes: []string{domain.ScopeRider}}, nil
+	case "Bearer ops-token":
+		return &domain.JwtClaims{Authenticated: true, Scopes: []string{domain.ScopeOps}}, nil
+	default:
+		return nil, errs.ErrUnauthorized(errors.New("invalid token"))
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	authHandler := newAuthHandler(zap.L(), &MockAuthService{})
+
+	app := fiber.New()
+	app.Get("/protected", func(ctx fiber.Ctx) error {
+		return response.Success(ctx, nil)
+	}, authHandler.Authenticate(true, domain.ScopeRider))
+
+	testCases := []struct {
+		name           string
+		token          string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "should success with rider token",
+			token:          "Bearer rider-token",
+			expectedStatus: http.StatusOK,
+			expectedCode:   response.SuccessCode,
+		},
+		{
+			name:           "should fail without token",
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   response.ErrCodeUnauthorized,
+		},
+		{
+			name:           "should fail with invalid token",
+			token:          "Bearer forged-token",
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   response.ErrCodeUnauthorized,
+		},
+		{
+			name:           "should fail without rider scope",
+			token:          "Bearer ops-token",
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   response.ErrCodeForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			if tc.token != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tc.token)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected status code: %d, got: %d", tc.expectedStatus, resp.StatusCode)
+			}
+			var body response.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			if body.Code != tc.expectedCode {
+				t.Errorf("expected code: %s, got: %s", tc.expectedCode, body.Code)
+			}
+		})
+	}
+}
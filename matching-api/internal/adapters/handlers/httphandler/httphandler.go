@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/services"
+	"github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
 	"github.com/gofiber/fiber/v3"
 	"go.uber.org/zap"
 )
@@ -14,6 +15,10 @@ type Handler struct {
 	logger        *zap.Logger
 	driverHandler *matchingHandler
 	authHandler   *authHandler
+	rateLimiter   *httpfiber.RateLimiter
+	healthCfg     HealthConfig
+	// requestTimeout bounds the calls made while handling a request
+	requestTimeout time.Duration
 }
 
 type ServerConfig struct {
@@ -22,7 +27,14 @@ type ServerConfig struct {
 	IdleTimeout  time.Duration
 }
 
-func NewHandler(serverCfg ServerConfig, logger *zap.Logger, accessLogger *zap.Logger, driverService services.MatchingService, apiVersion string) *Handler {
+type HealthConfig struct {
+	// ReadinessTimeout bounds the time all readiness checks may take
+	ReadinessTimeout time.Duration
+	// ReadinessChecks are run by name on every readiness probe
+	ReadinessChecks map[string]httpfiber.HealthCheck
+}
+
+func NewHandler(serverCfg ServerConfig, healthCfg HealthConfig, logger *zap.Logger, accessLogger *zap.Logger, driverService services.MatchingService, authService services.AuthService, rateLimiter *httpfiber.RateLimiter, apiVersion string) *Handler {
 	h := &Handler{
 		app: fiber.New(fiber.Config{
 			ReadTimeout:  serverCfg.ReadTimeout,
@@ -31,8 +43,12 @@ func NewHandler(serverCfg ServerConfig, logger *zap.Logger, accessLogger *zap.Lo
 		}),
 		logger:        logger,
 		driverHandler: newMatchingHandler(logger.With(zap.String("handler", "driver")), driverService),
-		authHandler:   newAuthHandler(logger.With(zap.String("handler", "auth"))),
+		authHandler:   newAuthHandler(logger.With(zap.String("handler", "auth")), authService),
+		rateLimiter:   rateLimiter,
+		healthCfg:     healthCfg,
 		apiVersion:    apiVersion,
+		// the response can not be written once the write timeout passed
+		requestTimeout: serverCfg.WriteTimeout,
 	}
 	h.applyRoutes(accessLogger)
 	return h
@@ -14,6 +14,11 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	defaultDriverCandidatesLimit = 5
+	maxDriverCandidatesLimit     = 50
+)
+
 type matchingHandler struct {
 	logger        *zap.Logger
 	driverService services.MatchingService
@@ -58,7 +63,7 @@ func (dh *matchingHandler) FindNearestDriver(ctx fiber.Ctx) error {
 
 	// call driver service
 	driver, distance, err := dh.driverService.FindNearestDriverLocation(
-		ctx.Context(),
+		ctx.UserContext(),
 		domain.UserLocation{Point: geo.(geojson.Point)},
 		radius,
 	)
@@ -75,3 +80,53 @@ func (dh *matchingHandler) FindNearestDriver(ctx fiber.Ctx) error {
 		Distance:       distance,
 	})
 }
+
+func (dh *matchingHandler) FindNearestDrivers(ctx fiber.Ctx) error {
+	// get context logger
+	logger := httpfiber.CtxLogger(ctx, dh.logger)
+
+	// parse query params
+	var radius float64
+	var err error
+	if radius, err = strconv.ParseFloat(ctx.Query("radius"), 64); err != nil {
+		logger.Error("invalid radius query param")
+		return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
+	}
+	limit := defaultDriverCandidatesLimit
+	if ctx.Query("limit") != "" {
+		if limit, err = strconv.Atoi(ctx.Query("limit")); err != nil || limit < 1 || limit > maxDriverCandidatesLimit {
+			logger.Error("invalid limit query param")
+			return response.Fail(ctx, response.ErrCodeInvalidQueryParam, response.ErrMgInvalidQueryParam, http.StatusBadRequest)
+		}
+	}
+
+	// parse body
+	geo, err := geojson.UnmarshalJSON(ctx.Body())
+	if err != nil {
+		logger.Error("could not unmarshal geojson data", zap.Error(err))
+		return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
+	}
+
+	// validate the type of geojson data
+	if geo.GetType() != geojson.TypePoint {
+		logger.Error("type of geojson data is not a point")
+		return response.Fail(ctx, response.ErrCodeInvalidPayload, response.ErrMsgInvalidPayload, http.StatusBadRequest)
+	}
+
+	// call driver service
+	candidates, err := dh.driverService.FindNearestDriverCandidates(
+		ctx.UserContext(),
+		domain.UserLocation{Point: geo.(geojson.Point)},
+		radius,
+		limit,
+	)
+	if err != nil {
+		logger.Error("could not find nearest drivers", zap.Error(err))
+		if errs.IsEntityNotFoundErr(err) {
+			return response.Fail(ctx, response.ErrCodeNotFound, response.ErrMsgNotFound, http.StatusNotFound)
+		}
+		return response.Fail(ctx, response.ErrCodeInternal, response.ErrMsgInternal, http.StatusInternalServerError)
+	}
+
+	return response.Success(ctx, candidates)
+}
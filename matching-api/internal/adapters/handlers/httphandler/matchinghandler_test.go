@@ -0,0 +1,155 @@
+package httphandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/services"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
+	"github.com/aniladanir/bitaksi-casestudy/shared/response"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// MockLocationFinder returns the first limit drivers ordered by distance
+type MockLocationFinder struct {
+	Candidates []domain.DriverCandidate
+}
+
+func (mlf *MockLocationFinder) GetNearestDriverLocation(ctx context.Context, userLocation domain.UserLocation, radius float64) (*domain.DriverLocation, *domain.Distance, error) {
+	if len(mlf.Candidates) == 0 {
+		return nil, nil, errs.ErrEntityNotFound("driver location")
+	}
+	return mlf.Candidates[0].DriverLocation, mlf.Candidates[0].Distance, nil
+}
+
+func (mlf *MockLocationFinder) GetNearestDriverLocations(ctx context.Context, userLocation domain.UserLocation, radius float64, limit int) ([]domain.DriverCandidate, error) {
+	if len(mlf.Candidates) == 0 {
+		return nil, errs.ErrEntityNotFound("driver location")
+	}
+	candidates := make([]domain.DriverCandidate, 0, limit)
+	for i := 0; i < len(mlf.Candidates) && i < limit; i++ {
+		candidates = append(candidates, mlf.Candidates[i])
+	}
+	return candidates, nil
+}
+
+func (*MockLocationFinder) Ping(ctx context.Context) error { return nil }
+
+func candidate(distance float64) domain.DriverCandidate {
+	return domain.DriverCandidate{
+		DriverLocation: &domain.DriverLocation{Point: geojson.Point{Type: geojson.TypePoint, Coordinates: geojson.Coordinate{29.0, 41.0}}},
+		Distance:       &domain.Distance{Distance: distance, Unit: "m"},
+	}
+}
+
+func TestFindNearestDrivers(t *testing.T) {
+	drivers := []domain.DriverCandidate{candidate(100), candidate(250), candidate(900)}
+
+	testCases := []struct {
+		name              string
+		payload           string
+		query             string
+		candidates        []domain.DriverCandidate
+		expectedStatus    int
+		expectedCode      string
+		expectedDistances []float64
+	}{
+		{
+			name:              "should rank candidates by distance",
+			payload:           `{"type":"Point","coordinates":[29.0,41.0]}`,
+			query:             "radius=1000",
+			candidates:        drivers,
+			expectedStatus:    http.StatusOK,
+			expectedCode:      response.SuccessCode,
+			expectedDistances: []float64{100, 250, 900},
+		},
+		{
+			name:              "should respect limit",
+			payload:           `{"type":"Point","coordinates":[29.0,41.0]}`,
+			query:             "radius=1000&limit=2",
+			candidates:        drivers,
+			expectedStatus:    http.StatusOK,
+			expectedCode:      response.SuccessCode,
+			expectedDistances: []float64{100, 250},
+		},
+		{
+			name:           "should fail due to no driver nearby",
+			payload:        `{"type":"Point","coordinates":[29.0,41.0]}`,
+			query:          "radius=1000",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   response.ErrCodeNotFound,
+		},
+		{
+			name:           "should fail due to limit over maximum",
+			payload:        `{"type":"Point","coordinates":[29.0,41.0]}`,
+			query:          "radius=1000&limit=51",
+			candidates:     drivers,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeInvalidQueryParam,
+		},
+		{
+			name:           "should fail due to zero limit",
+			payload:        `{"type":"Point","coordinates":[29.0,41.0]}`,
+			query:          "radius=1000&limit=0",
+			candidates:     drivers,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeInvalidQueryParam,
+		},
+		{
+			name:           "should fail due to polygon payload",
+			payload:        `{"type":"Polygon","coordinates":[[[28.9,41.0],[29.1,41.0],[29.1,41.2],[28.9,41.0]]]}`,
+			query:          "radius=1000",
+			candidates:     drivers,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   response.ErrCodeInvalidPayload,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matchingHandler := newMatchingHandler(zap.L(), services.NewDriverService(&MockLocationFinder{Candidates: tc.candidates}))
+
+			app := fiber.New()
+			app.Post("/match/drivers", matchingHandler.FindNearestDrivers)
+
+			req := httptest.NewRequest(http.MethodPost, "/match/drivers?"+tc.query, bytes.NewBufferString(tc.payload))
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected status code: %d, got: %d", tc.expectedStatus, resp.StatusCode)
+			}
+			var body struct {
+				response.Response
+				Data []domain.DriverCandidate `json:"data"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("cannot decode response body: %v", err)
+			}
+			if body.Code != tc.expectedCode {
+				t.Errorf("expected code: %s, got: %s", tc.expectedCode, body.Code)
+			}
+			if len(body.Data) != len(tc.expectedDistances) {
+				t.Fatalf("expected %d candidates, got: %d", len(tc.expectedDistances), len(body.Data))
+			}
+			for i, c := range body.Data {
+				if c.Rank != i+1 {
+					t.Errorf("expected rank %d at %d, got: %d", i+1, i, c.Rank)
+				}
+				if c.Distance.Distance != tc.expectedDistances[i] {
+					t.Errorf("expected distance %v at %d, got: %v", tc.expectedDistances[i], i, c.Distance.Distance)
+				}
+			}
+		})
+	}
+}
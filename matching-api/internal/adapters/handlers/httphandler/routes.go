@@ -3,6 +3,7 @@ package httphandler
 import (
 	"fmt"
 
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/domain"
 	"github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
 	"go.uber.org/zap"
 )
@@ -10,14 +11,25 @@ import (
 func (h *Handler) applyRoutes(accessLogger *zap.Logger) {
 	// apply common middlewares
 	h.app.Use(httpfiber.TracingMiddleware)
+	h.app.Use(httpfiber.DeadlineMiddleware(h.requestTimeout))
 	h.app.Use(httpfiber.AccessLogMiddleware(accessLogger))
+	h.app.Use(httpfiber.MetricsMiddleware)
+	h.app.Use(httpfiber.RecoverMiddleware(h.logger))
+
+	// Health API, probes are not authenticated
+	h.app.Get("/healthz", httpfiber.LivenessHandler)
+	h.app.Get("/readyz", httpfiber.ReadinessHandler(h.logger.With(zap.String("handler", "health")), h.healthCfg.ReadinessTimeout, h.healthCfg.ReadinessChecks))
 
 	api := h.app.Group(fmt.Sprintf("/api/%s", h.apiVersion))
 
 	// Auth API
-	api.Post("/auth", h.authHandler.Authenticate(false))
+	api.Post("/auth", h.authHandler.Authenticate(false), h.rateLimiter.Middleware("authenticate"))
+	api.Post("/auth/token", h.authHandler.IssueToken, h.rateLimiter.Middleware("issue-token"))
+	api.Post("/auth/refresh", h.authHandler.RefreshToken, h.rateLimiter.Middleware("refresh-token"))
+	api.Post("/auth/revoke", h.authHandler.RevokeToken, h.rateLimiter.Middleware("revoke-token"))
 
-	// Driver API
-	driverApi := api.Group("/match", h.authHandler.Authenticate(true))
-	driverApi.Post("/driver", h.driverHandler.FindNearestDriver)
+	// Driver API, clients are rate limited by token subject after authentication
+	driverApi := api.Group("/match", h.authHandler.Authenticate(true, domain.ScopeRider, domain.ScopeOps, domain.ScopeService))
+	driverApi.Post("/driver", h.driverHandler.FindNearestDriver, h.rateLimiter.Middleware("match-driver"))
+	driverApi.Post("/drivers", h.driverHandler.FindNearestDrivers, h.rateLimiter.Middleware("match-drivers"))
 }
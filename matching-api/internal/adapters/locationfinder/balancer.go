@@ -0,0 +1,274 @@
+package locationfinder
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/shared/circuitbreaker"
+)
+
+// Strategies picking the replica of a request
+const (
+	StrategyRoundRobin       = "round_robin"
+	StrategyLeastOutstanding = "least_outstanding"
+)
+
+// srvRetryInterval is the interval SRVName is resolved in while it resolved no replica yet
+const srvRetryInterval = time.Second
+
+// errNoEndpoint is returned when every replica that is not excluded has an open circuit breaker or
+// is ejected while others are available
+var errNoEndpoint = errors.New("no driver location api replica available")
+
+// BalancerConfig configures the replicas requests are balanced across
+type BalancerConfig struct {
+	// Strategy is StrategyRoundRobin or StrategyLeastOutstanding, round robin is the default
+	Strategy string
+	// URLs are the base urls of the replicas
+	URLs []url.URL
+	// SRVName is resolved to the replicas instead of URLs if set, e.g. _http._tcp.driver-location-api
+	SRVName string
+	// Scheme of the urls resolved from SRVName, http by default
+	Scheme string
+	// ResolveInterval is the interval SRVName is resolved in, 30 seconds by default
+	ResolveInterval time.Duration
+	// HealthCheckInterval is the interval replicas are checked in, zero disables health checks
+	HealthCheckInterval time.Duration
+	// NewCircuitBreaker creates the circuit breaker of the replica with name
+	NewCircuitBreaker func(name string) *circuitbreaker.CircuitBreaker
+}
+
+// endpoint is a replica of the driver location api
+type endpoint struct {
+	// url is the base url of the replica
+	url url.URL
+	cb  *circuitbreaker.CircuitBreaker
+	// outstanding is the number of requests in flight to the replica
+	outstanding atomic.Int64
+	// healthy is false while the replica is ejected by its health check
+	healthy atomic.Bool
+}
+
+func (e *endpoint) available() bool {
+	return e.healthy.Load() && e.cb.State() != circuitbreaker.StateOpen
+}
+
+// balancer picks the replica of every request, replicas are checked and resolved in the background
+type balancer struct {
+	cfg       BalancerConfig
+	endpoints atomic.Pointer[[]*endpoint]
+	next      atomic.Uint64
+	// check requests the health check of a replica
+	check     func(ctx context.Context, baseUrl url.URL) error
+	lookupSRV func(ctx context.Context, name string) ([]*net.SRV, error)
+}
+
+func newBalancer(cfg BalancerConfig, check func(ctx context.Context, baseUrl url.URL) error) *balancer {
+	if cfg.Scheme == "" {
+		cfg.Scheme = "http"
+	}
+	if cfg.ResolveInterval <= 0 {
+		cfg.ResolveInterval = 30 * time.Second
+	}
+	b := &balancer{
+		cfg:   cfg,
+		check: check,
+		lookupSRV: func(ctx context.Context, name string) ([]*net.SRV, error) {
+			_, srvs, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+			return srvs, err
+		},
+	}
+	b.setEndpoints(cfg.URLs)
+	return b
+}
+
+// setEndpoints replaces the replicas, replicas that are kept retain their circuit breaker and health
+func (b *balancer) setEndpoints(urls []url.URL) {
+	current := make(map[string]*endpoint)
+	if endpoints := b.endpoints.Load(); endpoints != nil {
+		for _, e := range *endpoints {
+			current[e.url.String()] = e
+		}
+	}
+
+	endpoints := make([]*endpoint, 0, len(urls))
+	for _, u := range urls {
+		if e, ok := current[u.String()]; ok {
+			endpoints = append(endpoints, e)
+			continue
+		}
+		e := &endpoint{
+			url: u,
+			cb:  b.cfg.NewCircuitBreaker(u.Host),
+		}
+		// replicas are healthy until their first health check fails
+		e.healthy.Store(true)
+		endpoints = append(endpoints, e)
+	}
+	b.endpoints.Store(&endpoints)
+}
+
+func (b *balancer) all() []*endpoint {
+	return *b.endpoints.Load()
+}
+
+// pick returns an available replica that is not excluded. Once every replica is unavailable it picks from all
+// replicas that are not excluded (panic mode), health checks failing on every replica at once are more likely
+// wrong than the replicas and circuit breakers still reject calls to replicas that fail them
+func (b *balancer) pick(exclude ...*endpoint) (*endpoint, error) {
+	endpoints := b.all()
+	available := make([]*endpoint, 0, len(endpoints))
+	panicking := true
+	for _, e := range endpoints {
+		if !e.available() {
+			continue
+		}
+		panicking = false
+		if !slices.Contains(exclude, e) {
+			available = append(available, e)
+		}
+	}
+	if panicking {
+		for _, e := range endpoints {
+			if !slices.Contains(exclude, e) {
+				available = append(available, e)
+			}
+		}
+	}
+	if len(available) == 0 {
+		return nil, errNoEndpoint
+	}
+
+	// ties of least outstanding requests are broken in round robin order
+	offset := int((b.next.Add(1) - 1) % uint64(len(available)))
+	if b.cfg.Strategy != StrategyLeastOutstanding {
+		return available[offset], nil
+	}
+	var picked *endpoint
+	for i := range available {
+		e := available[(offset+i)%len(available)]
+		if picked == nil || e.outstanding.Load() < picked.outstanding.Load() {
+			picked = e
+		}
+	}
+	return picked, nil
+}
+
+// hasOther reports whether a replica other than e is available
+func (b *balancer) hasOther(e *endpoint) bool {
+	for _, other := range b.all() {
+		if other != e && other.available() {
+			return true
+		}
+	}
+	return false
+}
+
+// Run resolves SRVName every resolve interval and checks the replicas every health check interval until ctx is done
+func (b *balancer) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	if b.cfg.SRVName != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.resolveEvery(ctx)
+		}()
+	}
+	if b.cfg.HealthCheckInterval > 0 {
+		b.checkEvery(ctx)
+	}
+	wg.Wait()
+	return nil
+}
+
+// resolveEvery resolves SRVName every resolve interval, the lookup is retried every srvRetryInterval
+// while there is no replica so that a failed first lookup does not leave the balancer without replicas
+func (b *balancer) resolveEvery(ctx context.Context) {
+	for {
+		b.resolve(ctx)
+
+		interval := b.cfg.ResolveInterval
+		if len(b.all()) == 0 {
+			interval = min(interval, srvRetryInterval)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (b *balancer) checkEvery(ctx context.Context) {
+	ticker := time.NewTicker(b.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		b.checkAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// resolve replaces the replicas with the targets of the srv record if SRVName is set,
+// the last resolved replicas are kept if the lookup fails
+func (b *balancer) resolve(ctx context.Context) {
+	if b.cfg.SRVName == "" {
+		return
+	}
+	srvs, err := b.lookupSRV(ctx, b.cfg.SRVName)
+	if err != nil {
+		if ctx.Err() == nil {
+			srvLookupErrorsTotal.Inc()
+		}
+		return
+	}
+	urls := make([]url.URL, 0, len(srvs))
+	for _, srv := range srvs {
+		urls = append(urls, url.URL{
+			Scheme: b.cfg.Scheme,
+			Host:   net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port))),
+		})
+	}
+	b.setEndpoints(urls)
+}
+
+// checkAll checks the replicas concurrently within the health check interval and ejects failing ones
+func (b *balancer) checkAll(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, b.cfg.HealthCheckInterval)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, e := range b.all() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			healthy := b.check(checkCtx, e.url) == nil
+			if ctx.Err() != nil {
+				// shutting down, the check did not fail because of the replica
+				return
+			}
+			e.healthy.Store(healthy)
+			endpointHealthy.WithLabelValues(e.url.Host).Set(boolToFloat(healthy))
+		}()
+	}
+	wg.Wait()
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
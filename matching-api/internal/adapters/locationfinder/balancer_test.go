@@ -0,0 +1,195 @@
+package locationfinder
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/shared/circuitbreaker"
+)
+
+func newTestBalancer(strategy string, hosts []string, check func(ctx context.Context, baseUrl url.URL) error) *balancer {
+	urls := make([]url.URL, 0, len(hosts))
+	for _, host := range hosts {
+		urls = append(urls, url.URL{Scheme: "http", Host: host})
+	}
+	return newBalancer(BalancerConfig{
+		Strategy:            strategy,
+		URLs:                urls,
+		HealthCheckInterval: time.Second,
+		NewCircuitBreaker: func(name string) *circuitbreaker.CircuitBreaker {
+			return circuitbreaker.NewCircuitBreaker(circuitbreaker.WithName("test"), circuitbreaker.WithMaxFailures(1))
+		},
+	}, check)
+}
+
+func pickHost(t *testing.T, b *balancer, exclude ...*endpoint) string {
+	t.Helper()
+	e, err := b.pick(exclude...)
+	if err != nil {
+		t.Fatalf("expected replica, got: %v", err)
+	}
+	return e.url.Host
+}
+
+func TestBalancerPick(t *testing.T) {
+	t.Run("should pick in round robin order", func(t *testing.T) {
+		b := newTestBalancer(StrategyRoundRobin, []string{"a", "b", "c"}, nil)
+		for _, expected := range []string{"a", "b", "c", "a"} {
+			if host := pickHost(t, b); host != expected {
+				t.Errorf("expected %s, got: %s", expected, host)
+			}
+		}
+	})
+
+	t.Run("should pick least outstanding", func(t *testing.T) {
+		b := newTestBalancer(StrategyLeastOutstanding, []string{"a", "b", "c"}, nil)
+		endpoints := b.all()
+		endpoints[0].outstanding.Store(2)
+		endpoints[1].outstanding.Store(1)
+		endpoints[2].outstanding.Store(3)
+		for i := 0; i < 3; i++ {
+			if host := pickHost(t, b); host != "b" {
+				t.Errorf("expected replica with least outstanding requests, got: %s", host)
+			}
+		}
+	})
+
+	t.Run("should skip excluded and unavailable replicas", func(t *testing.T) {
+		b := newTestBalancer(StrategyRoundRobin, []string{"a", "b", "c"}, nil)
+		endpoints := b.all()
+		endpoints[1].healthy.Store(false)
+		circuitbreaker.Execute(context.Background(), endpoints[2].cb, func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, errors.New("unavailable")
+		})
+		for i := 0; i < 3; i++ {
+			if host := pickHost(t, b); host != "a" {
+				t.Errorf("expected only available replica, got: %s", host)
+			}
+		}
+		if _, err := b.pick(endpoints[0]); !errors.Is(err, errNoEndpoint) {
+			t.Errorf("expected no replica to be available, got: %v", err)
+		}
+	})
+
+	t.Run("should pick from all replicas once every replica is unavailable", func(t *testing.T) {
+		b := newTestBalancer(StrategyRoundRobin, []string{"a", "b", "c"}, nil)
+		endpoints := b.all()
+		for _, e := range endpoints {
+			e.healthy.Store(false)
+		}
+		picked := map[string]bool{}
+		for i := 0; i < 3; i++ {
+			picked[pickHost(t, b)] = true
+		}
+		if len(picked) != 3 {
+			t.Errorf("expected ejected replicas to be picked in panic mode, got: %v", picked)
+		}
+		if host := pickHost(t, b, endpoints[0], endpoints[1]); host != "c" {
+			t.Errorf("expected only replica that is not excluded, got: %s", host)
+		}
+		if _, err := b.pick(endpoints...); !errors.Is(err, errNoEndpoint) {
+			t.Errorf("expected no replica when all are excluded, got: %v", err)
+		}
+	})
+}
+
+func TestBalancerHealthCheck(t *testing.T) {
+	unhealthy := map[string]bool{"b": true}
+	b := newTestBalancer(StrategyRoundRobin, []string{"a", "b"}, func(ctx context.Context, baseUrl url.URL) error {
+		if unhealthy[baseUrl.Host] {
+			return errors.New("not ready")
+		}
+		return nil
+	})
+
+	b.checkAll(context.Background())
+	for i := 0; i < 2; i++ {
+		if host := pickHost(t, b); host != "a" {
+			t.Errorf("expected unhealthy replica to be ejected, got: %s", host)
+		}
+	}
+
+	delete(unhealthy, "b")
+	b.checkAll(context.Background())
+	picked := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		picked[pickHost(t, b)] = true
+	}
+	if !picked["b"] {
+		t.Error("expected recovered replica to be picked again")
+	}
+}
+
+func TestBalancerResolve(t *testing.T) {
+	targets := []*net.SRV{{Target: "a.", Port: 9650}, {Target: "b.", Port: 9650}}
+	b := newTestBalancer(StrategyRoundRobin, nil, nil)
+	b.cfg.SRVName = "_http._tcp.driver-location-api"
+	b.lookupSRV = func(ctx context.Context, name string) ([]*net.SRV, error) {
+		if targets == nil {
+			return nil, errors.New("lookup failed")
+		}
+		return targets, nil
+	}
+
+	b.resolve(context.Background())
+	endpoints := b.all()
+	if len(endpoints) != 2 || endpoints[0].url.String() != "http://a:9650" {
+		t.Fatalf("expected resolved replicas, got: %v", endpoints)
+	}
+	endpoints[0].healthy.Store(false)
+
+	// kept replicas retain their state
+	targets = []*net.SRV{{Target: "a.", Port: 9650}, {Target: "c.", Port: 9650}}
+	b.resolve(context.Background())
+	resolved := b.all()
+	if len(resolved) != 2 || resolved[0] != endpoints[0] || resolved[1].url.Host != "c:9650" {
+		t.Fatalf("expected replica a to be kept and b to be replaced by c, got: %v", resolved)
+	}
+
+	// failed lookups keep the last replicas
+	targets = nil
+	b.resolve(context.Background())
+	if len(b.all()) != 2 {
+		t.Errorf("expected replicas to be kept on failed lookup, got: %v", b.all())
+	}
+}
+
+func TestBalancerRetriesResolve(t *testing.T) {
+	b := newTestBalancer(StrategyRoundRobin, nil, nil)
+	b.cfg.SRVName = "_http._tcp.driver-location-api"
+	b.cfg.HealthCheckInterval = 0
+	b.cfg.ResolveInterval = time.Hour
+
+	// the first lookup fails, health checks are disabled
+	var lookups atomic.Int32
+	b.lookupSRV = func(ctx context.Context, name string) ([]*net.SRV, error) {
+		if lookups.Add(1) == 1 {
+			return nil, errors.New("lookup failed")
+		}
+		return []*net.SRV{{Target: "a.", Port: 9650}}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	deadline := time.Now().Add(5 * srvRetryInterval)
+	for len(b.all()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := b.pick(); err != nil {
+		t.Errorf("expected failed lookup to be retried, got: %v", err)
+	}
+}
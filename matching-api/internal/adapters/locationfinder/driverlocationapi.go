@@ -14,26 +14,61 @@ import (
 	"github.com/aniladanir/bitaksi-casestudy/shared/circuitbreaker"
 	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
 	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
+	"github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
 	"github.com/aniladanir/bitaksi-casestudy/shared/response"
+	"github.com/aniladanir/bitaksi-casestudy/shared/retry"
 	"github.com/gofiber/fiber/v3"
 	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type driverLocationApiClient struct {
 	fasthttp.Client
-	url url.URL
-	cb  *circuitbreaker.CircuitBreaker
+	// apiPath is joined to the base url of the replica
+	apiPath  string
+	balancer *balancer
+	// hedger is nil if hedging is disabled
+	hedger *hedger
+	retry  retry.Policy
+	creds  Credentials
+	// timeout bounds every attempt, the deadline of the caller applies if it is earlier
+	timeout time.Duration
 }
 
-func NewDriverLocationApiClient(url url.URL, version string, timeout time.Duration, cb *circuitbreaker.CircuitBreaker) *driverLocationApiClient {
-	return &driverLocationApiClient{
-		url: *url.JoinPath(fmt.Sprintf("/api/%s", version)),
+// NewDriverLocationApiClient creates the http client balancing requests across the replicas of balancerCfg,
+// transient failures are retried with retryPolicy and slow calls are hedged with hedgingCfg.
+// Replicas are health checked once Run is called
+func NewDriverLocationApiClient(balancerCfg BalancerConfig, hedgingCfg HedgingConfig, version string, timeout time.Duration, retryPolicy retry.Policy, creds Credentials) *driverLocationApiClient {
+	if retryPolicy.IsRetryable == nil {
+		retryPolicy.IsRetryable = isRetryable
+	}
+	c := &driverLocationApiClient{
+		apiPath: fmt.Sprintf("/api/%s", version),
 		Client: fasthttp.Client{
 			WriteTimeout: timeout,
 			ReadTimeout:  timeout,
+			TLSConfig:    creds.TLSConfig,
 		},
-		cb: cb,
+		retry:   retryPolicy,
+		creds:   creds,
+		timeout: timeout,
+	}
+	// replicas are ejected while they are not ready
+	c.balancer = newBalancer(balancerCfg, func(ctx context.Context, baseUrl url.URL) error {
+		return c.get(ctx, baseUrl.JoinPath("/readyz"))
+	})
+	if hedgingCfg.Enabled {
+		c.hedger = newHedger(hedgingCfg)
 	}
+	return c
+}
+
+// Run health checks the replicas until ctx is done
+func (c *driverLocationApiClient) Run(ctx context.Context) error {
+	return c.balancer.Run(ctx)
 }
 
 func (c *driverLocationApiClient) GetNearestDriverLocation(ctx context.Context, userLocation domain.UserLocation, radius float64) (*domain.DriverLocation, *domain.Distance, error) {
@@ -45,11 +80,9 @@ func (c *driverLocationApiClient) GetNearestDriverLocation(ctx context.Context,
 		Location geojson.Point `json:"location"`
 	}
 
-	// build request url
-	targetUrl := c.url.JoinPath("/driver/location")
-	q := targetUrl.Query()
+	// build request query
+	q := url.Values{}
 	q.Add("radius", strconv.FormatFloat(radius, 'f', 5, 64))
-	targetUrl.RawQuery = q.Encode()
 
 	// serialize geojson point to json
 	pointJson, err := json.Marshal(userLocation)
@@ -57,60 +90,269 @@ func (c *driverLocationApiClient) GetNearestDriverLocation(ctx context.Context,
 		return nil, nil, errs.ErrInternal(err)
 	}
 
-	reqFunc := func() (any, error) {
-		// build request
-		req := fasthttp.AcquireRequest()
-		defer fasthttp.ReleaseRequest(req)
-		req.SetRequestURI(targetUrl.String())
-		req.Header.SetMethod(fasthttp.MethodPost)
-		req.Header.SetContentType(fiber.MIMEApplicationJSON)
-		req.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
-		req.SetBody(pointJson)
-
-		// Create response object
-		resp := fasthttp.AcquireResponse()
-		defer fasthttp.ReleaseResponse(resp)
-
-		// Send the request
-		client := &fasthttp.Client{
-			ReadTimeout:  0,
-			WriteTimeout: 0,
-		}
-		if err := client.Do(req, resp); err != nil {
-			return nil, fmt.Errorf("could not make request: %w", err)
-		}
-
-		// handle response
-		payload := &response.Response{
-			Data: new(ResponsePayload),
-		}
-		if err := json.Unmarshal(resp.Body(), payload); err != nil {
-			return false, errs.ErrInternal(fmt.Errorf("could not decode payload: %w", err))
+	candidate, err := execute(ctx, c, func(ctx context.Context, baseUrl url.URL) (domain.DriverCandidate, error) {
+		data := new(ResponsePayload)
+		if err := c.post(ctx, c.apiUrl(baseUrl, "/driver/location", q), pointJson, data); err != nil {
+			return domain.DriverCandidate{}, err
 		}
-		if !payload.Success {
-			switch resp.StatusCode() {
-			case http.StatusNotFound:
-				return nil, errs.ErrEntityNotFound("driver location")
-			default:
-				return nil, errs.ErrInternal(errors.New(payload.Message))
-			}
-		}
-
-		data := payload.Data.(*ResponsePayload)
-		return map[string]any{
-			"location": &domain.DriverLocation{
+		return domain.DriverCandidate{
+			DriverLocation: &domain.DriverLocation{
 				Point: data.Location,
 			},
-			"distance": &domain.Distance{
+			Distance: &domain.Distance{
 				Distance: data.Distance.Distance,
 				Unit:     data.Distance.Unit,
 			},
 		}, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return candidate.DriverLocation, candidate.Distance, nil
+}
+
+func (c *driverLocationApiClient) GetNearestDriverLocations(ctx context.Context, userLocation domain.UserLocation, radius float64, limit int) ([]domain.DriverCandidate, error) {
+	type ResponsePayload []struct {
+		Distance struct {
+			Distance float64 `json:"distance"`
+			Unit     string  `json:"unit"`
+		} `json:"distance"`
+		Location struct {
+			ID string `json:"id"`
+			geojson.Point
+		} `json:"location"`
 	}
 
-	data, err := c.cb.Execute(reqFunc)
+	// build request query
+	q := url.Values{}
+	q.Add("radius", strconv.FormatFloat(radius, 'f', 5, 64))
+	q.Add("limit", strconv.Itoa(limit))
+
+	// serialize geojson point to json
+	pointJson, err := json.Marshal(userLocation)
 	if err != nil {
-		return nil, nil, err
+		return nil, errs.ErrInternal(err)
+	}
+
+	return execute(ctx, c, func(ctx context.Context, baseUrl url.URL) ([]domain.DriverCandidate, error) {
+		var data ResponsePayload
+		if err := c.post(ctx, c.apiUrl(baseUrl, "/driver/locations/nearest", q), pointJson, &data); err != nil {
+			return nil, err
+		}
+		candidates := make([]domain.DriverCandidate, 0, len(data))
+		for _, d := range data {
+			candidates = append(candidates, domain.DriverCandidate{
+				DriverLocation: &domain.DriverLocation{
+					ID:    d.Location.ID,
+					Point: d.Location.Point,
+				},
+				Distance: &domain.Distance{
+					Distance: d.Distance.Distance,
+					Unit:     d.Distance.Unit,
+				},
+			})
+		}
+		return candidates, nil
+	})
+}
+
+// execute calls fn with the base url of a replica picked by the balancer, retries prefer another replica.
+// Attempts are hedged on another replica if hedging is enabled
+func execute[T any](ctx context.Context, c *driverLocationApiClient, fn func(ctx context.Context, baseUrl url.URL) (T, error)) (T, error) {
+	var last *endpoint
+	return retry.Do(ctx, c.retry, func(ctx context.Context) (T, error) {
+		var (
+			result T
+			err    error
+		)
+		if c.hedger != nil {
+			last, result, err = hedge(ctx, c, fn, last)
+		} else {
+			last, result, err = call(ctx, c, fn, nil, last)
+		}
+		return result, err
+	})
+}
+
+// call calls fn through the circuit breaker of a replica picked by the balancer, avoid is only picked if no other
+// replica is available. Replicas whose circuit breaker rejects the call are skipped, onPick is called with
+// every replica fn is tried on if it is not nil
+func call[T any](ctx context.Context, c *driverLocationApiClient, fn func(ctx context.Context, baseUrl url.URL) (T, error), onPick func(e *endpoint), avoid *endpoint) (*endpoint, T, error) {
+	var zero T
+	var rejected []*endpoint
+	for {
+		e, err := c.balancer.pick(append(rejected, avoid)...)
+		if errors.Is(err, errNoEndpoint) && avoid != nil {
+			e, err = c.balancer.pick(rejected...)
+		}
+		if err != nil {
+			return avoid, zero, err
+		}
+		if onPick != nil {
+			onPick(e)
+		}
+
+		e.outstanding.Add(1)
+		result, err := circuitbreaker.Execute(ctx, e.cb, func(ctx context.Context) (T, error) {
+			return fn(ctx, e.url)
+		})
+		e.outstanding.Add(-1)
+		if errors.Is(err, circuitbreaker.ErrOpen) {
+			rejected = append(rejected, e)
+			continue
+		}
+		return e, result, err
+	}
+}
+
+// isRetryable also retries connections closed by the server before responding,
+// requests are safe to send again as they only query locations
+func isRetryable(err error) bool {
+	return errors.Is(err, fasthttp.ErrConnectionClosed) || retry.IsRetryable(err)
+}
+
+// Ping succeeds once a replica that is not ejected and whose circuit breaker is not open is live
+func (c *driverLocationApiClient) Ping(ctx context.Context) error {
+	err := errNoEndpoint
+	for _, e := range c.balancer.all() {
+		if !e.available() {
+			continue
+		}
+		if err = c.get(ctx, e.url.JoinPath("/healthz")); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// apiUrl returns the url of path on the replica with baseUrl
+func (c *driverLocationApiClient) apiUrl(baseUrl url.URL, path string, query url.Values) *url.URL {
+	targetUrl := baseUrl.JoinPath(c.apiPath, path)
+	targetUrl.RawQuery = query.Encode()
+	return targetUrl
+}
+
+// get requests targetUrl and expects a 200 response, it is used for health checks
+func (c *driverLocationApiClient) get(ctx context.Context, targetUrl *url.URL) error {
+	req := fasthttp.AcquireRequest()
+	req.SetRequestURI(targetUrl.String())
+	req.Header.SetMethod(fasthttp.MethodGet)
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("could not reach driver location api: %w", err)
+	}
+	defer fasthttp.ReleaseResponse(resp)
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("driver location api responded with status %d", resp.StatusCode())
+	}
+	return nil
+}
+
+// post sends body to targetUrl and decodes the data field of the response envelope into data
+func (c *driverLocationApiClient) post(ctx context.Context, targetUrl *url.URL, body []byte, data any) (err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "POST "+targetUrl.Path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", fasthttp.MethodPost),
+			attribute.String("url.full", targetUrl.String()),
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	// build request
+	req := fasthttp.AcquireRequest()
+	req.SetRequestURI(targetUrl.String())
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType(fiber.MIMEApplicationJSON)
+	req.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+	if c.creds.Token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+c.creds.Token)
+	}
+	httpfiber.InjectTraceHeaders(ctx, &req.Header)
+	req.SetBody(body)
+
+	// Send the request
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("could not make request: %w", err)
+	}
+	defer fasthttp.ReleaseResponse(resp)
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode()))
+
+	// handle response
+	if retry.IsRetryableStatus(resp.StatusCode()) {
+		return errs.ErrInternal(&retry.StatusError{StatusCode: resp.StatusCode()})
+	}
+	payload := &response.Response{
+		Data: data,
+	}
+	if err := json.Unmarshal(resp.Body(), payload); err != nil {
+		return errs.ErrInternal(fmt.Errorf("could not decode payload: %w", err))
+	}
+	if !payload.Success {
+		switch resp.StatusCode() {
+		case http.StatusNotFound:
+			return errs.ErrEntityNotFound("driver location")
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return errs.ErrInternal(fmt.Errorf("driver location api rejected credentials: %s", payload.Message))
+		default:
+			return errs.ErrInternal(errors.New(payload.Message))
+		}
+	}
+
+	return nil
+}
+
+// do sends req over the pooled connections of the client and releases it, the returned response must be released
+// by the caller. The attempt is bounded by the client timeout or the deadline of ctx if it is earlier, and is
+// abandoned with the error of ctx once ctx is done, e.g. when the deadline of the incoming request passes, the
+// rider disconnects or a hedged call wins. An abandoned attempt keeps its pooled connection until the fasthttp call returns, at the
+// latest at its deadline
+func (c *driverLocationApiClient) do(ctx context.Context, req *fasthttp.Request) (*fasthttp.Response, error) {
+	if err := ctx.Err(); err != nil {
+		fasthttp.ReleaseRequest(req)
+		return nil, err
+	}
+	var deadline time.Time
+	if c.timeout > 0 {
+		deadline = time.Now().Add(c.timeout)
+	}
+	if ctxDeadline, ok := ctx.Deadline(); ok && (deadline.IsZero() || ctxDeadline.Before(deadline)) {
+		deadline = ctxDeadline
+	}
+
+	resp := fasthttp.AcquireResponse()
+	done := make(chan error, 1)
+	go func() {
+		if deadline.IsZero() {
+			done <- c.Do(req, resp)
+			return
+		}
+		done <- c.DoDeadline(req, resp, deadline)
+	}()
+
+	select {
+	case err := <-done:
+		fasthttp.ReleaseRequest(req)
+		if err != nil {
+			fasthttp.ReleaseResponse(resp)
+			return nil, err
+		}
+		return resp, nil
+	case <-ctx.Done():
+		// fasthttp calls can not be interrupted, the request and response are released once the call returns
+		go func() {
+			<-done
+			fasthttp.ReleaseRequest(req)
+			fasthttp.ReleaseResponse(resp)
+		}()
+		return nil, ctx.Err()
 	}
-	return data.(map[string]any)["location"].(*domain.DriverLocation), data.(map[string]any)["distance"].(*domain.Distance), nil
 }
@@ -0,0 +1,205 @@
+package locationfinder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/shared/circuitbreaker"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
+	"github.com/aniladanir/bitaksi-casestudy/shared/retry"
+)
+
+const nearestDriverResponse = `{"success":true,"code":"BT-0000","data":{"distance":{"distance":1.5,"unit":"km"},"location":{"type":"Point","coordinates":[29.1,40.9]}}}`
+
+func newTestClient(t *testing.T, handlers ...http.HandlerFunc) (*driverLocationApiClient, *circuitbreaker.CircuitBreaker) {
+	t.Helper()
+
+	urls := make([]url.URL, 0, len(handlers))
+	for _, handler := range handlers {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		serverUrl, _ := url.Parse(server.URL)
+		urls = append(urls, *serverUrl)
+	}
+
+	client := NewDriverLocationApiClient(BalancerConfig{
+		URLs: urls,
+		NewCircuitBreaker: func(name string) *circuitbreaker.CircuitBreaker {
+			return circuitbreaker.NewCircuitBreaker(
+				circuitbreaker.WithName("test"),
+				circuitbreaker.WithMaxFailures(2),
+				circuitbreaker.WithIsFailure(func(err error) bool { return !errs.IsEntityNotFoundErr(err) }),
+			)
+		},
+	}, HedgingConfig{}, "v1", time.Second, retry.Policy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Multiplier:     2,
+	}, Credentials{})
+	// circuit breaker of the first replica
+	return client, client.balancer.all()[0].cb
+}
+
+func TestGetNearestDriverLocationRetry(t *testing.T) {
+	userLocation := domain.UserLocation{Point: geojson.Point{Type: "Point", Coordinates: []float64{29, 41}}}
+
+	t.Run("should retry unavailable responses", func(t *testing.T) {
+		var attempts atomic.Int32
+		client, cb := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(nearestDriverResponse))
+		})
+
+		location, distance, err := client.GetNearestDriverLocation(context.Background(), userLocation, 1000)
+		if err != nil {
+			t.Fatalf("expected retry to succeed, got: %v", err)
+		}
+		if location.Point.Coordinates[0] != 29.1 || distance.Distance != 1.5 {
+			t.Errorf("unexpected result: %v %v", location, distance)
+		}
+		if attempts.Load() != 2 {
+			t.Errorf("expected 2 attempts, got: %d", attempts.Load())
+		}
+		if cb.State() != circuitbreaker.StateClosed {
+			t.Errorf("expected circuit breaker to stay closed, got: %s", cb.State())
+		}
+	})
+
+	t.Run("should not retry not found", func(t *testing.T) {
+		var attempts atomic.Int32
+		client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success":false,"code":"BT-0002","message":"Not Found"}`))
+		})
+
+		if _, _, err := client.GetNearestDriverLocation(context.Background(), userLocation, 1000); !errs.IsEntityNotFoundErr(err) {
+			t.Errorf("expected not found error, got: %v", err)
+		}
+		if attempts.Load() != 1 {
+			t.Errorf("expected 1 attempt, got: %d", attempts.Load())
+		}
+	})
+
+	t.Run("should stop retrying once circuit breaker opens", func(t *testing.T) {
+		var attempts atomic.Int32
+		client, cb := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		})
+
+		if _, _, err := client.GetNearestDriverLocation(context.Background(), userLocation, 1000); err == nil {
+			t.Fatal("expected error")
+		}
+		if attempts.Load() != 2 {
+			t.Errorf("expected attempts to stop when circuit breaker opens, got: %d", attempts.Load())
+		}
+		if cb.State() != circuitbreaker.StateOpen {
+			t.Errorf("expected circuit breaker to open, got: %s", cb.State())
+		}
+	})
+}
+
+func TestGetNearestDriverLocationContext(t *testing.T) {
+	userLocation := domain.UserLocation{Point: geojson.Point{Type: "Point", Coordinates: []float64{29, 41}}}
+
+	newBlockingClient := func(t *testing.T) (*driverLocationApiClient, *circuitbreaker.CircuitBreaker, chan struct{}) {
+		received := make(chan struct{}, 3)
+		release := make(chan struct{})
+		client, cb := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			received <- struct{}{}
+			<-release
+		})
+		// handlers must return before the server closes
+		t.Cleanup(func() { close(release) })
+		return client, cb, received
+	}
+
+	t.Run("should abandon call when context is canceled", func(t *testing.T) {
+		client, cb, received := newBlockingClient(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-received
+			cancel()
+		}()
+
+		start := time.Now()
+		if _, _, err := client.GetNearestDriverLocation(ctx, userLocation, 1000); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected canceled error, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Errorf("expected call to return on cancel, took: %s", elapsed)
+		}
+		if cb.State() != circuitbreaker.StateClosed {
+			t.Errorf("expected canceled call not to trip circuit breaker, got: %s", cb.State())
+		}
+	})
+
+	t.Run("should honour deadline of the caller", func(t *testing.T) {
+		client, _, _ := newBlockingClient(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		if _, _, err := client.GetNearestDriverLocation(ctx, userLocation, 1000); err == nil {
+			t.Error("expected error")
+		}
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Errorf("expected call to return at the deadline, took: %s", elapsed)
+		}
+	})
+
+	t.Run("should apply client timeout", func(t *testing.T) {
+		client, _, _ := newBlockingClient(t)
+		client.timeout = 50 * time.Millisecond
+
+		start := time.Now()
+		if _, _, err := client.GetNearestDriverLocation(context.Background(), userLocation, 1000); err == nil {
+			t.Error("expected timeout error")
+		}
+		// every attempt times out
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Errorf("expected attempts to time out, took: %s", elapsed)
+		}
+	})
+}
+
+func TestGetNearestDriverLocationFailover(t *testing.T) {
+	userLocation := domain.UserLocation{Point: geojson.Point{Type: "Point", Coordinates: []float64{29, 41}}}
+
+	var failing, healthy atomic.Int32
+	client, cb := newTestClient(t,
+		func(w http.ResponseWriter, r *http.Request) {
+			failing.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+		func(w http.ResponseWriter, r *http.Request) {
+			healthy.Add(1)
+			w.Write([]byte(nearestDriverResponse))
+		},
+	)
+
+	// retries go to the other replica
+	for i := 0; i < 4; i++ {
+		if _, _, err := client.GetNearestDriverLocation(context.Background(), userLocation, 1000); err != nil {
+			t.Fatalf("expected failover to succeed, got: %v", err)
+		}
+	}
+	if failing.Load() != 2 || healthy.Load() != 4 {
+		t.Errorf("expected 2 calls to the failing replica and 4 to the healthy one, got: %d %d", failing.Load(), healthy.Load())
+	}
+	if cb.State() != circuitbreaker.StateOpen {
+		t.Errorf("expected circuit breaker of the failing replica to open, got: %s", cb.State())
+	}
+}
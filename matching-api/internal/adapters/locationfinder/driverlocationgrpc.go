@@ -0,0 +1,191 @@
+package locationfinder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/shared/circuitbreaker"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
+	driverlocationv1 "github.com/aniladanir/bitaksi-casestudy/shared/proto/driverlocation/v1"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	// registers the client side health checking of the service config
+	_ "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type driverLocationGrpcClient struct {
+	client  driverlocationv1.DriverLocationServiceClient
+	health  healthpb.HealthClient
+	timeout time.Duration
+	cb      *circuitbreaker.CircuitBreaker
+}
+
+// serviceConfig balances calls across the addresses the target resolves to and skips replicas
+// whose grpc health service is not serving
+const serviceConfig = `{"loadBalancingConfig":[{"round_robin":{}}],"healthCheckConfig":{"serviceName":""}}`
+
+// NewDriverLocationGrpcClient creates the grpc client, calls are balanced in round robin across the replicas
+// address resolves to, e.g. dns:///driver-location-api:9651. Unlike the http client, cb is shared by all replicas
+// since grpc picks the replica of a call, a failing replica is only skipped once its health service stops serving
+func NewDriverLocationGrpcClient(address string, timeout time.Duration, cb *circuitbreaker.CircuitBreaker, creds Credentials) (*driverLocationGrpcClient, error) {
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+	}
+	if creds.TLSConfig != nil {
+		opts[0] = grpc.WithTransportCredentials(credentials.NewTLS(creds.TLSConfig))
+	}
+	if creds.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials{
+			token:  creds.Token,
+			secure: creds.TLSConfig != nil,
+		}))
+	}
+
+	conn, err := grpc.NewClient(address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create grpc client: %w", err)
+	}
+	return &driverLocationGrpcClient{
+		client:  driverlocationv1.NewDriverLocationServiceClient(conn),
+		health:  healthpb.NewHealthClient(conn),
+		timeout: timeout,
+		cb:      cb,
+	}, nil
+}
+
+func (c *driverLocationGrpcClient) GetNearestDriverLocation(ctx context.Context, userLocation domain.UserLocation, radius float64) (*domain.DriverLocation, *domain.Distance, error) {
+	if err := userLocation.IsValid(); err != nil {
+		return nil, nil, errs.ErrInternal(err)
+	}
+	req := &driverlocationv1.FindNearestDriverRequest{
+		Point:  pointToProto(userLocation.Point),
+		Radius: radius,
+	}
+
+	candidate, err := circuitbreaker.Execute(ctx, c.cb, func(ctx context.Context) (domain.DriverCandidate, error) {
+		ctx, cancel := c.withTimeout(ctx)
+		defer cancel()
+
+		resp, err := c.client.FindNearestDriver(ctx, req)
+		if err != nil {
+			return domain.DriverCandidate{}, fromStatusError(err)
+		}
+		return candidateFromProto(resp.GetDriver()), nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return candidate.DriverLocation, candidate.Distance, nil
+}
+
+func (c *driverLocationGrpcClient) GetNearestDriverLocations(ctx context.Context, userLocation domain.UserLocation, radius float64, limit int) ([]domain.DriverCandidate, error) {
+	if err := userLocation.IsValid(); err != nil {
+		return nil, errs.ErrInternal(err)
+	}
+	req := &driverlocationv1.FindNearestDriversRequest{
+		Point:  pointToProto(userLocation.Point),
+		Radius: radius,
+		Limit:  int32(limit),
+	}
+
+	return circuitbreaker.Execute(ctx, c.cb, func(ctx context.Context) ([]domain.DriverCandidate, error) {
+		ctx, cancel := c.withTimeout(ctx)
+		defer cancel()
+
+		resp, err := c.client.FindNearestDrivers(ctx, req)
+		if err != nil {
+			return nil, fromStatusError(err)
+		}
+		candidates := make([]domain.DriverCandidate, 0, len(resp.GetDrivers()))
+		for _, d := range resp.GetDrivers() {
+			candidates = append(candidates, candidateFromProto(d))
+		}
+		return candidates, nil
+	})
+}
+
+// tokenCredentials attaches a bearer token to every call
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (tc tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + tc.token}, nil
+}
+
+func (tc tokenCredentials) RequireTransportSecurity() bool {
+	return tc.secure
+}
+
+func (c *driverLocationGrpcClient) Ping(ctx context.Context) error {
+	if state := c.cb.State(); state == circuitbreaker.StateOpen {
+		return fmt.Errorf("driver location api circuit breaker is %s", state)
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return fmt.Errorf("could not reach driver location api: %w", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("driver location api is %s", resp.GetStatus())
+	}
+	return nil
+}
+
+// withTimeout bounds the call with the client timeout, zero means no timeout
+func (c *driverLocationGrpcClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// fromStatusError maps grpc status errors to the errors returned by the http client
+func fromStatusError(err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return errs.ErrEntityNotFound("driver location")
+	default:
+		return errs.ErrInternal(fmt.Errorf("could not make request: %w", err))
+	}
+}
+
+func pointToProto(p geojson.Point) *driverlocationv1.Point {
+	return &driverlocationv1.Point{
+		Longitude: p.Coordinates[0],
+		Latitude:  p.Coordinates[1],
+	}
+}
+
+func candidateFromProto(d *driverlocationv1.DriverDistance) domain.DriverCandidate {
+	return domain.DriverCandidate{
+		DriverLocation: &domain.DriverLocation{
+			ID: d.GetLocation().GetId(),
+			Point: geojson.Point{
+				Type: geojson.TypePoint,
+				Coordinates: geojson.Coordinate{
+					d.GetLocation().GetPoint().GetLongitude(),
+					d.GetLocation().GetPoint().GetLatitude(),
+				},
+			},
+		},
+		Distance: &domain.Distance{
+			Distance: d.GetDistance().GetDistance(),
+			Unit:     d.GetDistance().GetUnit(),
+		},
+	}
+}
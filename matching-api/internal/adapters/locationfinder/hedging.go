@@ -0,0 +1,135 @@
+package locationfinder
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// hedgeWindowSize is the number of recent latencies the hedge delay is computed from
+	hedgeWindowSize = 200
+	// hedgeMinSamples latencies must be recorded before the percentile is used
+	hedgeMinSamples = 20
+)
+
+// HedgingConfig configures hedged calls, a call is sent to a second replica if the first one did not
+// answer within the Percentile of recent latencies
+type HedgingConfig struct {
+	Enabled bool
+	// Percentile of recent latencies a call may take before it is hedged, e.g. 0.95
+	Percentile float64
+	// MinDelay is the lower bound of the hedge delay so that replicas are not hedged on jitter
+	MinDelay time.Duration
+	// MaxDelay is the upper bound of the hedge delay, it is used until enough latencies are recorded
+	MaxDelay time.Duration
+}
+
+// hedger tracks the latencies of recent calls and derives the hedge delay from them. Calls canceled because the
+// other call answered first record their elapsed time as a lower bound, otherwise hedging would hide the slow
+// calls it hedges and the delay would slide to MinDelay
+type hedger struct {
+	cfg       HedgingConfig
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+	// delay is recomputed on the next read once a latency is recorded
+	delay time.Duration
+	dirty bool
+}
+
+func newHedger(cfg HedgingConfig) *hedger {
+	hedgeDelaySeconds.Set(cfg.MaxDelay.Seconds())
+	return &hedger{
+		cfg:       cfg,
+		latencies: make([]time.Duration, 0, hedgeWindowSize),
+		delay:     cfg.MaxDelay,
+	}
+}
+
+func (h *hedger) record(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.latencies) < hedgeWindowSize {
+		h.latencies = append(h.latencies, latency)
+	} else {
+		h.latencies[h.next] = latency
+	}
+	h.next = (h.next + 1) % hedgeWindowSize
+	h.dirty = true
+}
+
+// hedgeDelay returns the percentile of recent latencies bounded by the configured delays
+func (h *hedger) hedgeDelay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.dirty || len(h.latencies) < hedgeMinSamples {
+		return h.delay
+	}
+
+	sorted := slices.Clone(h.latencies)
+	slices.Sort(sorted)
+	index := int(math.Ceil(h.cfg.Percentile*float64(len(sorted)))) - 1
+	index = max(0, min(index, len(sorted)-1))
+	h.delay = max(h.cfg.MinDelay, min(sorted[index], h.cfg.MaxDelay))
+	h.dirty = false
+	hedgeDelaySeconds.Set(h.delay.Seconds())
+	return h.delay
+}
+
+// hedge calls fn on a replica and on a second one if the first did not answer within the hedge delay,
+// the first successful result is returned and the other call is canceled. It fails once all calls failed
+func hedge[T any](ctx context.Context, c *driverLocationApiClient, fn func(ctx context.Context, baseUrl url.URL) (T, error), avoid *endpoint) (*endpoint, T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		e      *endpoint
+		result T
+		err    error
+		hedged bool
+	}
+	outcomes := make(chan outcome, 2)
+	var primary atomic.Pointer[endpoint]
+	run := func(hedged bool, onPick func(e *endpoint), avoidReplica *endpoint) {
+		start := time.Now()
+		e, result, err := call(ctx, c, fn, onPick, avoidReplica)
+		if err == nil || ctx.Err() != nil {
+			c.hedger.record(time.Since(start))
+		}
+		outcomes <- outcome{e: e, result: result, err: err, hedged: hedged}
+	}
+	go run(false, primary.Store, avoid)
+
+	timer := time.NewTimer(c.hedger.hedgeDelay())
+	defer timer.Stop()
+
+	var failed outcome
+	for pending := 1; pending > 0; {
+		select {
+		case <-timer.C:
+			// the hedge goes to another replica, the call is not hedged if there is none
+			e := primary.Load()
+			if e == nil || !c.balancer.hasOther(e) {
+				continue
+			}
+			hedgesTotal.Inc()
+			pending++
+			go run(true, nil, e)
+		case o := <-outcomes:
+			pending--
+			if o.err == nil {
+				if o.hedged {
+					hedgeWinsTotal.Inc()
+				}
+				return o.e, o.result, nil
+			}
+			failed = o
+		}
+	}
+	return failed.e, failed.result, failed.err
+}
@@ -0,0 +1,148 @@
+package locationfinder
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/shared/circuitbreaker"
+	"github.com/aniladanir/bitaksi-casestudy/shared/geojson"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHedgerDelay(t *testing.T) {
+	h := newHedger(HedgingConfig{
+		Enabled:    true,
+		Percentile: 0.9,
+		MinDelay:   5 * time.Millisecond,
+		MaxDelay:   80 * time.Millisecond,
+	})
+
+	// max delay until enough latencies are recorded
+	for i := 1; i < hedgeMinSamples; i++ {
+		h.record(time.Millisecond)
+	}
+	if delay := h.hedgeDelay(); delay != 80*time.Millisecond {
+		t.Errorf("expected max delay without enough latencies, got: %s", delay)
+	}
+
+	// 1ms to 100ms
+	h = newHedger(h.cfg)
+	for i := 1; i <= 100; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+	if delay := h.hedgeDelay(); delay != 80*time.Millisecond {
+		t.Errorf("expected percentile to be bounded by max delay, got: %s", delay)
+	}
+	for i := 0; i < hedgeWindowSize; i++ {
+		h.record(time.Duration(i%10) * time.Millisecond)
+	}
+	if delay := h.hedgeDelay(); delay != 8*time.Millisecond {
+		t.Errorf("expected 90th percentile of recent latencies, got: %s", delay)
+	}
+	for i := 0; i < hedgeWindowSize; i++ {
+		h.record(time.Millisecond)
+	}
+	if delay := h.hedgeDelay(); delay != 5*time.Millisecond {
+		t.Errorf("expected percentile to be bounded by min delay, got: %s", delay)
+	}
+}
+
+func TestGetNearestDriverLocationHedging(t *testing.T) {
+	userLocation := domain.UserLocation{Point: geojson.Point{Type: "Point", Coordinates: []float64{29, 41}}}
+	hedging := HedgingConfig{Enabled: true, Percentile: 0.95, MinDelay: time.Millisecond, MaxDelay: 20 * time.Millisecond}
+
+	t.Run("should take the result of the hedged request", func(t *testing.T) {
+		release := make(chan struct{})
+		var slow, fast atomic.Int32
+		client, cb := newTestClient(t,
+			func(w http.ResponseWriter, r *http.Request) {
+				slow.Add(1)
+				<-release
+			},
+			func(w http.ResponseWriter, r *http.Request) {
+				fast.Add(1)
+				w.Write([]byte(nearestDriverResponse))
+			},
+		)
+		t.Cleanup(func() { close(release) })
+		client.hedger = newHedger(hedging)
+
+		hedges, wins := testutil.ToFloat64(hedgesTotal), testutil.ToFloat64(hedgeWinsTotal)
+		start := time.Now()
+		if _, _, err := client.GetNearestDriverLocation(context.Background(), userLocation, 1000); err != nil {
+			t.Fatalf("expected hedged request to succeed, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+			t.Errorf("expected hedged request to answer, took: %s", elapsed)
+		}
+		if slow.Load() != 1 || fast.Load() != 1 {
+			t.Errorf("expected one request to each replica, got: %d %d", slow.Load(), fast.Load())
+		}
+		if testutil.ToFloat64(hedgesTotal)-hedges != 1 || testutil.ToFloat64(hedgeWinsTotal)-wins != 1 {
+			t.Error("expected hedge and hedge win to be counted")
+		}
+		if cb.State() != circuitbreaker.StateClosed {
+			t.Errorf("expected canceled request not to count as failure, got: %s", cb.State())
+		}
+	})
+
+	t.Run("should not hedge fast requests", func(t *testing.T) {
+		var calls atomic.Int32
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Write([]byte(nearestDriverResponse))
+		}
+		client, _ := newTestClient(t, handler, handler)
+		client.hedger = newHedger(HedgingConfig{Enabled: true, Percentile: 0.95, MinDelay: time.Second, MaxDelay: time.Second})
+
+		hedges := testutil.ToFloat64(hedgesTotal)
+		for i := 0; i < 3; i++ {
+			if _, _, err := client.GetNearestDriverLocation(context.Background(), userLocation, 1000); err != nil {
+				t.Fatalf("expected request to succeed, got: %v", err)
+			}
+		}
+		if calls.Load() != 3 || testutil.ToFloat64(hedgesTotal) != hedges {
+			t.Errorf("expected no hedged requests, got %d calls", calls.Load())
+		}
+	})
+}
+
+func TestHedgeDelaySlowTail(t *testing.T) {
+	// every tenth attempt is slow and takes 30ms to 50ms in turn, the others take 1ms.
+	// The 95th percentile of the workload is 40ms
+	const p95 = 40 * time.Millisecond
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	client, _ := newTestClient(t, noop, noop)
+	client.hedger = newHedger(HedgingConfig{Enabled: true, Percentile: 0.95, MinDelay: time.Millisecond, MaxDelay: 100 * time.Millisecond})
+
+	var attempts atomic.Int32
+	fn := func(ctx context.Context, baseUrl url.URL) (struct{}, error) {
+		latency := time.Millisecond
+		if n := attempts.Add(1); n%10 == 0 {
+			latency = 30*time.Millisecond + time.Duration(n/10%5)*5*time.Millisecond
+		}
+		select {
+		case <-ctx.Done():
+			return struct{}{}, ctx.Err()
+		case <-time.After(latency):
+			return struct{}{}, nil
+		}
+	}
+
+	for i := 0; i < hedgeWindowSize; i++ {
+		if _, _, err := hedge(context.Background(), client, fn, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// hedged calls record their elapsed time, the slowest calls would drop out of the latencies and the
+	// delay would slide to min delay otherwise
+	if delay := client.hedger.hedgeDelay(); delay < p95*3/4 || delay > 2*p95 {
+		t.Errorf("expected hedge delay near %s, got: %s", p95, delay)
+	}
+}
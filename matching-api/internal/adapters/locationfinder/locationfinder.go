@@ -2,10 +2,25 @@ package locationfinder
 
 import (
 	"context"
+	"crypto/tls"
 
 	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/domain"
 )
 
+const tracerName = "github.com/aniladanir/bitaksi-casestudy/matching-api/internal/adapters/locationfinder"
+
 type LocationFinder interface {
 	GetNearestDriverLocation(ctx context.Context, userLocation domain.UserLocation, radius float64) (*domain.DriverLocation, *domain.Distance, error)
+	GetNearestDriverLocations(ctx context.Context, userLocation domain.UserLocation, radius float64, limit int) ([]domain.DriverCandidate, error)
+	// Ping checks that the driver location api is reachable and its circuit breaker is not open,
+	// it does not go through the circuit breaker
+	Ping(ctx context.Context) error
+}
+
+// Credentials are attached to every request made to the driver location api
+type Credentials struct {
+	// Token is sent as bearer token
+	Token string
+	// TLSConfig enables tls and presents its client certificate for mtls authentication
+	TLSConfig *tls.Config
 }
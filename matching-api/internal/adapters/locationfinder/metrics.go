@@ -0,0 +1,33 @@
+package locationfinder
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	endpointHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "driver_location_api_endpoint_healthy",
+		Help: "Health check result of a driver location api replica, 1 if healthy and 0 if ejected.",
+	}, []string{"endpoint"})
+
+	srvLookupErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "driver_location_api_srv_lookup_errors_total",
+		Help: "Failed srv lookups of the driver location api replicas.",
+	})
+
+	hedgesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "driver_location_api_hedged_requests_total",
+		Help: "Requests sent to a second driver location api replica as the first did not answer within the hedge delay.",
+	})
+
+	hedgeWinsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "driver_location_api_hedge_wins_total",
+		Help: "Hedged requests that answered before the request they hedged.",
+	})
+
+	hedgeDelaySeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "driver_location_api_hedge_delay_seconds",
+		Help: "Current delay after which driver location api requests are hedged.",
+	})
+)
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Scopes
+const (
+	ScopeRider   = "rider"
+	ScopeDriver  = "driver"
+	ScopeOps     = "ops"
+	ScopeService = "service"
+)
+
+// Token Types
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+type JwtClaims struct {
+	jwt.RegisteredClaims
+	Authenticated bool     `json:"authenticated"`
+	TokenType     string   `json:"tokenType,omitempty"`
+	Scopes        []string `json:"scopes,omitempty"`
+}
+
+// Validate is called by the parser after the registered claims are validated
+func (c JwtClaims) Validate() error {
+	if !c.Authenticated {
+		return errors.New("token is not authenticated")
+	}
+	return nil
+}
+
+// HasAnyScope reports whether the claims carry at least one of the scopes
+func (c JwtClaims) HasAnyScope(scopes ...string) bool {
+	for _, scope := range scopes {
+		if slices.Contains(c.Scopes, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// Client is an api client that authenticates with its id and secret
+type Client struct {
+	ID         string
+	SecretHash string
+	// Scopes are granted to the tokens issued to the client
+	Scopes []string
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	// ExpiresIn is the lifetime of the access token in seconds
+	ExpiresIn int64 `json:"expiresIn"`
+}
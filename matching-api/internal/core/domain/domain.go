@@ -13,6 +13,7 @@ const (
 )
 
 type DriverLocation struct {
+	ID string `json:"id,omitempty"`
 	geojson.Point
 }
 
@@ -23,6 +24,13 @@ func (dl DriverLocation) IsValid() error {
 	return nil
 }
 
+// DriverCandidate is a driver ranked by its distance to the user, starting from 1
+type DriverCandidate struct {
+	Rank           int             `json:"rank"`
+	DriverLocation *DriverLocation `json:"driverLocation"`
+	Distance       *Distance       `json:"distance"`
+}
+
 type UserLocation struct {
 	geojson.Point
 }
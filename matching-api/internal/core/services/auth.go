@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/adapters/authstore"
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/jwtauth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummySecretHash is compared when the client does not exist, so that unknown and
+// known client ids take the same time to reject
+var dummySecretHash = []byte("$2a$10$DH2nhChN73BlzOJotgQPsO1uNDOWUcyYNhjW7xhShicjLRMyjDsZO")
+
+type AuthService interface {
+	IssueToken(ctx context.Context, clientID, clientSecret string) (*domain.TokenPair, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*domain.TokenPair, error)
+	RevokeToken(ctx context.Context, token string) error
+	Authenticate(ctx context.Context, accessToken string) (*domain.JwtClaims, error)
+}
+
+type TokenConfig struct {
+	Issuer          string
+	Audience        string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+type authService struct {
+	clientStore     authstore.ClientStore
+	revocationStore authstore.RevocationStore
+	signer          *jwtauth.Signer
+	verifier        *jwtauth.Verifier
+	cfg             TokenConfig
+}
+
+func NewAuthService(clientStore authstore.ClientStore, revocationStore authstore.RevocationStore, signer *jwtauth.Signer, verifier *jwtauth.Verifier, cfg TokenConfig) *authService {
+	return &authService{
+		clientStore:     clientStore,
+		revocationStore: revocationStore,
+		signer:          signer,
+		verifier:        verifier,
+		cfg:             cfg,
+	}
+}
+
+func (as *authService) IssueToken(ctx context.Context, clientID, clientSecret string) (*domain.TokenPair, error) {
+	client, err := as.clientStore.GetClient(ctx, clientID)
+	if err != nil && !errs.IsEntityNotFoundErr(err) {
+		return nil, err
+	}
+
+	secretHash := dummySecretHash
+	if client != nil {
+		secretHash = []byte(client.SecretHash)
+	}
+	if err := bcrypt.CompareHashAndPassword(secretHash, []byte(clientSecret)); err != nil || client == nil {
+		return nil, errs.ErrUnauthorized(errors.New("invalid client credentials"))
+	}
+
+	return as.issueTokenPair(client)
+}
+
+func (as *authService) RefreshToken(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	claims, err := as.parse(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != domain.TokenTypeRefresh {
+		return nil, errs.ErrUnauthorized(errors.New("token is not a refresh token"))
+	}
+
+	// the client may be removed or its scopes may change after the refresh token is issued
+	client, err := as.clientStore.GetClient(ctx, claims.Subject)
+	if err != nil {
+		if errs.IsEntityNotFoundErr(err) {
+			return nil, errs.ErrUnauthorized(errors.New("client does not exist"))
+		}
+		return nil, err
+	}
+
+	// refresh tokens are single use, a concurrent refresh may have used the token since it is parsed
+	revoked, err := as.revoke(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, errs.ErrUnauthorized(errors.New("token is revoked"))
+	}
+
+	return as.issueTokenPair(client)
+}
+
+func (as *authService) RevokeToken(ctx context.Context, token string) error {
+	claims, err := as.parse(ctx, token)
+	if err != nil {
+		// expired tokens can not be used anyway
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil
+		}
+		return err
+	}
+	_, err = as.revoke(ctx, claims)
+	return err
+}
+
+func (as *authService) Authenticate(ctx context.Context, accessToken string) (*domain.JwtClaims, error) {
+	claims, err := as.parse(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType == domain.TokenTypeRefresh {
+		return nil, errs.ErrUnauthorized(errors.New("refresh token can not be used for authentication"))
+	}
+	return claims, nil
+}
+
+// parse verifies the token and rejects it if it is revoked
+func (as *authService) parse(ctx context.Context, token string) (*domain.JwtClaims, error) {
+	claims := &domain.JwtClaims{}
+	if _, err := as.verifier.Parse(token, claims); err != nil {
+		return nil, errs.ErrUnauthorized(err)
+	}
+	if claims.ID == "" {
+		return claims, nil
+	}
+
+	revoked, err := as.revocationStore.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, errs.ErrInternal(fmt.Errorf("could not check token revocation: %w", err))
+	}
+	if revoked {
+		return nil, errs.ErrUnauthorized(errors.New("token is revoked"))
+	}
+	return claims, nil
+}
+
+// revoke reports false if the token was already revoked
+func (as *authService) revoke(ctx context.Context, claims *domain.JwtClaims) (bool, error) {
+	if claims.ID == "" {
+		return false, errs.ErrUnauthorized(errors.New("token has no id to revoke"))
+	}
+	revoked, err := as.revocationStore.Revoke(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return false, errs.ErrInternal(fmt.Errorf("could not revoke token: %w", err))
+	}
+	return revoked, nil
+}
+
+func (as *authService) issueTokenPair(client *domain.Client) (*domain.TokenPair, error) {
+	now := time.Now()
+	accessToken, err := as.sign(client, domain.TokenTypeAccess, now, as.cfg.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := as.sign(client, domain.TokenTypeRefresh, now, as.cfg.RefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(as.cfg.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (as *authService) sign(client *domain.Client, tokenType string, now time.Time, ttl time.Duration) (string, error) {
+	claims := domain.JwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   client.ID,
+			Issuer:    as.cfg.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Authenticated: true,
+		TokenType:     tokenType,
+		Scopes:        client.Scopes,
+	}
+	if as.cfg.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{as.cfg.Audience}
+	}
+
+	token, err := as.signer.Sign(claims)
+	if err != nil {
+		return "", errs.ErrInternal(err)
+	}
+	return token, nil
+}
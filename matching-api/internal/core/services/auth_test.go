@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/adapters/authstore"
+	"github.com/aniladanir/bitaksi-casestudy/matching-api/internal/core/domain"
+	"github.com/aniladanir/bitaksi-casestudy/shared/errs"
+	"github.com/aniladanir/bitaksi-casestudy/shared/jwtauth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testSecret = "test-secret-of-at-least-32-bytes"
+
+func newTestAuthService(t *testing.T) *authService {
+	t.Helper()
+
+	secretHash, err := bcrypt.GenerateFromPassword([]byte("client-secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("could not hash secret: %v", err)
+	}
+	signer, err := jwtauth.NewSigner(jwtauth.SignerConfig{Algorithm: "HS256", Secret: testSecret})
+	if err != nil {
+		t.Fatalf("could not create signer: %v", err)
+	}
+	verifier, err := jwtauth.NewVerifier(jwtauth.Config{Algorithm: "HS256", Secret: testSecret, Issuer: "bitaksi", Audience: "matching-api"})
+	if err != nil {
+		t.Fatalf("could not create verifier: %v", err)
+	}
+
+	return NewAuthService(
+		authstore.NewStaticClientStore([]domain.Client{{ID: "client", SecretHash: string(secretHash), Scopes: []string{domain.ScopeRider}}}),
+		authstore.NewMemoryRevocationStore(),
+		signer,
+		verifier,
+		TokenConfig{
+			Issuer:          "bitaksi",
+			Audience:        "matching-api",
+			AccessTokenTTL:  time.Minute,
+			RefreshTokenTTL: time.Hour,
+		},
+	)
+}
+
+func TestIssueToken(t *testing.T) {
+	as := newTestAuthService(t)
+
+	testCases := []struct {
+		name     string
+		clientID string
+		secret   string
+		expected bool
+	}{
+		{name: "should issue token", clientID: "client", secret: "client-secret", expected: true},
+		{name: "should reject wrong secret", clientID: "client", secret: "wrong"},
+		{name: "should reject unknown client", clientID: "unknown", secret: "client-secret"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenPair, err := as.IssueToken(context.Background(), tc.clientID, tc.secret)
+			if !tc.expected {
+				if !errs.IsUnauthorizedErr(err) {
+					t.Errorf("expected unauthorized error, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			claims, err := as.Authenticate(context.Background(), tokenPair.AccessToken)
+			if err != nil {
+				t.Fatalf("expected access token to authenticate, got: %v", err)
+			}
+			if !claims.HasAnyScope(domain.ScopeOps, domain.ScopeRider) || claims.HasAnyScope(domain.ScopeDriver) {
+				t.Errorf("expected rider scope only, got: %v", claims.Scopes)
+			}
+			if _, err := as.Authenticate(context.Background(), tokenPair.RefreshToken); err == nil {
+				t.Error("expected refresh token to be rejected by authenticate")
+			}
+		})
+	}
+}
+
+func TestRefreshAndRevokeToken(t *testing.T) {
+	ctx := context.Background()
+	as := newTestAuthService(t)
+
+	tokenPair, err := as.IssueToken(ctx, "client", "client-secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := as.RefreshToken(ctx, tokenPair.AccessToken); !errs.IsUnauthorizedErr(err) {
+		t.Errorf("expected access token to be rejected by refresh, got: %v", err)
+	}
+	refreshed, err := as.RefreshToken(ctx, tokenPair.RefreshToken)
+	if err != nil {
+		t.Fatalf("expected refresh to succeed, got: %v", err)
+	}
+	if _, err := as.RefreshToken(ctx, tokenPair.RefreshToken); !errs.IsUnauthorizedErr(err) {
+		t.Errorf("expected used refresh token to be rejected, got: %v", err)
+	}
+
+	if err := as.RevokeToken(ctx, refreshed.AccessToken); err != nil {
+		t.Fatalf("expected revoke to succeed, got: %v", err)
+	}
+	if _, err := as.Authenticate(ctx, refreshed.AccessToken); !errs.IsUnauthorizedErr(err) {
+		t.Errorf("expected revoked access token to be rejected, got: %v", err)
+	}
+	if _, err := as.Authenticate(ctx, tokenPair.AccessToken); err != nil {
+		t.Errorf("expected other access token to stay valid, got: %v", err)
+	}
+}
+
+// racingRevocationStore holds every revocation check until all refreshes passed it
+type racingRevocationStore struct {
+	authstore.RevocationStore
+	checks sync.WaitGroup
+}
+
+func (s *racingRevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	revoked, err := s.RevocationStore.IsRevoked(ctx, id)
+	s.checks.Done()
+	s.checks.Wait()
+	return revoked, err
+}
+
+func TestConcurrentRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	as := newTestAuthService(t)
+
+	tokenPair, err := as.IssueToken(ctx, "client", "client-secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// every refresh passes the revocation check of parse, only one of them may revoke the token
+	const refreshes = 20
+	store := &racingRevocationStore{RevocationStore: as.revocationStore}
+	store.checks.Add(refreshes)
+	as.revocationStore = store
+
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for i := 0; i < refreshes; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := as.RefreshToken(ctx, tokenPair.RefreshToken); err == nil {
+				succeeded.Add(1)
+			} else if !errs.IsUnauthorizedErr(err) {
+				t.Errorf("expected unauthorized error, got: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded.Load() != 1 {
+		t.Errorf("expected refresh token to be used once, got: %d", succeeded.Load())
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 type MatchingService interface {
 	FindNearestDriverLocation(ctx context.Context, userLocation domain.UserLocation, radius float64) (*domain.DriverLocation, *domain.Distance, error)
+	FindNearestDriverCandidates(ctx context.Context, userLocation domain.UserLocation, radius float64, limit int) ([]domain.DriverCandidate, error)
 }
 
 type matchingService struct {
@@ -33,3 +34,22 @@ func (ds *matchingService) FindNearestDriverLocation(ctx context.Context, userLo
 	}
 	return driverLocation, distanceToUser, nil
 }
+
+func (ds *matchingService) FindNearestDriverCandidates(ctx context.Context, userLocation domain.UserLocation, radius float64, limit int) ([]domain.DriverCandidate, error) {
+	candidates, err := ds.locationFinder.GetNearestDriverLocations(ctx, userLocation, radius, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// location finder returns candidates ordered by distance, rank them accordingly
+	for i := range candidates {
+		if err := candidates[i].DriverLocation.IsValid(); err != nil {
+			return nil, errs.ErrInternal(fmt.Errorf("got invalid data from location finder: %w", err))
+		}
+		if err := candidates[i].Distance.IsValid(); err != nil {
+			return nil, errs.ErrInternal(fmt.Errorf("got invalid distance from location finder: %w", err))
+		}
+		candidates[i].Rank = i + 1
+	}
+	return candidates, nil
+}
@@ -1,11 +1,16 @@
 package circuitbreaker
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"sync"
 	"time"
 )
 
+// ErrOpen is returned without calling the request function while the breaker is open,
+// or while it is half open and all probe requests are taken
+var ErrOpen = errors.New("circuit breaker is open")
+
 type CircuitBreakerState int
 
 const (
@@ -14,73 +19,277 @@ const (
 	StateHalfOpen
 )
 
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// StateChangeHook is called with the name of the breaker on every state transition,
+// hooks run after the lock is released so they may call State
+type StateChangeHook func(name string, from, to CircuitBreakerState)
+
+type transition struct {
+	from, to CircuitBreakerState
+}
+
+// outcome of an admitted call
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	// outcomeIgnored is not recorded, the caller canceled the call
+	outcomeIgnored
+)
+
 type CircuitBreaker struct {
+	name         string
 	state        CircuitBreakerState
-	failureCount int
+	window       window
+	isFailure    func(err error) bool
 	lastAttempt  time.Time
 	mu           sync.RWMutex
-	maxFailures  int
 	retryTimeout time.Duration
+	// generation changes on every transition so that calls admitted in a previous state are not counted
+	generation uint64
+	// halfOpenMaxRequests probes must succeed in half open state to close the breaker
+	halfOpenMaxRequests int
+	halfOpenRequests    int
+	halfOpenSuccesses   int
+	onStateChange       []StateChangeHook
+	// transitions are collected under the lock and passed to the hooks once it is released
+	transitions []transition
+	now         func() time.Time
 }
 
 type CircuitBreakerOption func(*CircuitBreaker)
 
+// WithName labels the metrics of the circuit breaker
+func WithName(name string) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.name = name
+	}
+}
+
+// WithMaxFailures trips the breaker after maxFailures consecutive failures, this is the default mode
 func WithMaxFailures(maxFailures int) CircuitBreakerOption {
 	return func(cb *CircuitBreaker) {
-		cb.maxFailures = maxFailures
+		cb.window = &consecutiveWindow{maxFailures: maxFailures}
+	}
+}
+
+// WithCountWindow trips the breaker when failureRate of the last size calls failed,
+// the rate is not evaluated until minRequests calls are recorded
+func WithCountWindow(size int, failureRate float64, minRequests int) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.window = newCountWindow(size, rateThreshold{failureRate: failureRate, minRequests: minRequests})
 	}
 }
+
+// WithTimeWindow trips the breaker when failureRate of the calls made within the last duration failed,
+// the rate is not evaluated until minRequests calls are recorded
+func WithTimeWindow(duration time.Duration, failureRate float64, minRequests int) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.window = newTimeWindow(duration, rateThreshold{failureRate: failureRate, minRequests: minRequests})
+	}
+}
+
+// WithIsFailure classifies the errors that count as failures, other errors are returned to the
+// caller but count as successful calls. Every error is a failure by default
+func WithIsFailure(isFailure func(err error) bool) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.isFailure = isFailure
+	}
+}
+
 func WithRetryTimeout(retryTimeout time.Duration) CircuitBreakerOption {
 	return func(cb *CircuitBreaker) {
 		cb.retryTimeout = retryTimeout
 	}
 }
 
+// WithHalfOpenMaxRequests sets the number of probe requests let through in half open state,
+// the breaker closes once all of them succeed and opens again on the first failure
+func WithHalfOpenMaxRequests(maxRequests int) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.halfOpenMaxRequests = maxRequests
+	}
+}
+
+// WithOnStateChange registers a hook called on every state transition
+func WithOnStateChange(hook StateChangeHook) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.onStateChange = append(cb.onStateChange, hook)
+	}
+}
+
 func NewCircuitBreaker(opts ...CircuitBreakerOption) *CircuitBreaker {
 	cb := &CircuitBreaker{
-		state:        StateClosed,
-		maxFailures:  3,
-		retryTimeout: 15 * time.Second,
+		name:                "default",
+		state:               StateClosed,
+		window:              &consecutiveWindow{maxFailures: 3},
+		isFailure:           func(err error) bool { return err != nil },
+		retryTimeout:        15 * time.Second,
+		halfOpenMaxRequests: 1,
+		now:                 time.Now,
 	}
 	for _, opt := range opts {
 		opt(cb)
 	}
+	if cb.halfOpenMaxRequests < 1 {
+		cb.halfOpenMaxRequests = 1
+	}
+	stateGauge.WithLabelValues(cb.name).Set(float64(cb.state))
 	return cb
 }
 
-func (cb *CircuitBreaker) Execute(requestFunc func() (interface{}, error)) (interface{}, error) {
+// State returns the current state, an open breaker whose retry timeout passed is reported as half open
+func (cb *CircuitBreaker) State() CircuitBreakerState {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	if cb.state == StateOpen && cb.now().Sub(cb.lastAttempt) >= cb.retryTimeout {
+		return StateHalfOpen
+	}
+	return cb.state
+}
+
+// Execute calls fn unless the breaker is open, the lock is not held while fn runs.
+// Calls are not recorded if ctx is canceled when they return, as the caller gave up on them.
+// Calls running past the deadline of ctx are failures, a hanging dependency would never trip the breaker otherwise
+func Execute[T any](ctx context.Context, cb *CircuitBreaker, fn func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	generation, err := cb.beforeRequest()
+	if err != nil {
+		return zero, err
+	}
+
+	result, err := fn(ctx)
+	switch {
+	case err == nil:
+		cb.afterRequest(generation, outcomeSuccess)
+	case errors.Is(ctx.Err(), context.Canceled):
+		cb.afterRequest(generation, outcomeIgnored)
+	case ctx.Err() != nil, cb.isFailure(err):
+		cb.afterRequest(generation, outcomeFailure)
+	default:
+		cb.afterRequest(generation, outcomeSuccess)
+	}
+	if err != nil {
+		return zero, err
+	}
+	return result, nil
+}
+
+// beforeRequest admits the call and returns the generation it is admitted in
+func (cb *CircuitBreaker) beforeRequest() (uint64, error) {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	defer cb.unlock()
 
 	if cb.state == StateOpen {
-		if time.Since(cb.lastAttempt) < cb.retryTimeout {
-			return nil, fmt.Errorf("circuit breaker is open")
+		if cb.now().Sub(cb.lastAttempt) < cb.retryTimeout {
+			return 0, ErrOpen
 		}
-		cb.state = StateHalfOpen
+		cb.setState(StateHalfOpen)
 	}
+	if cb.state == StateHalfOpen {
+		if cb.halfOpenRequests >= cb.halfOpenMaxRequests {
+			return 0, ErrOpen
+		}
+		cb.halfOpenRequests++
+	}
+	return cb.generation, nil
+}
 
-	result, err := requestFunc()
-	if err != nil {
+func (cb *CircuitBreaker) afterRequest(generation uint64, result outcome) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	if generation != cb.generation {
+		return
+	}
+	switch result {
+	case outcomeSuccess:
+		cb.handleSuccess()
+	case outcomeFailure:
 		cb.handleFailure()
-		return nil, err
+	case outcomeIgnored:
+		// give the probe slot back so that another request can probe
+		if cb.state == StateHalfOpen {
+			cb.halfOpenRequests--
+		}
+	}
+}
+
+// unlock releases the lock and calls the hooks with the transitions made while it was held
+func (cb *CircuitBreaker) unlock() {
+	transitions := cb.transitions
+	cb.transitions = nil
+	cb.mu.Unlock()
+
+	for _, t := range transitions {
+		for _, hook := range cb.onStateChange {
+			hook(cb.name, t.from, t.to)
+		}
 	}
-	cb.handleSuccess()
-	return result, nil
 }
 
+// handleSuccess must be called with the lock held
 func (cb *CircuitBreaker) handleSuccess() {
-	cb.failureCount = 0
-	if cb.state == StateHalfOpen {
-		cb.state = StateClosed
-		fmt.Println("Circuit breaker closed")
+	switch cb.state {
+	case StateClosed:
+		cb.window.record(cb.now(), false)
+	case StateHalfOpen:
+		cb.halfOpenSuccesses++
+		if cb.halfOpenSuccesses >= cb.halfOpenMaxRequests {
+			cb.setState(StateClosed)
+		}
 	}
 }
 
+// handleFailure must be called with the lock held
 func (cb *CircuitBreaker) handleFailure() {
-	cb.failureCount++
-	if cb.failureCount >= cb.maxFailures {
-		cb.state = StateOpen
-		cb.lastAttempt = time.Now()
-		fmt.Println("Circuit breaker opened")
+	switch cb.state {
+	case StateClosed:
+		now := cb.now()
+		cb.window.record(now, true)
+		if cb.window.shouldTrip(now) {
+			cb.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		cb.setState(StateOpen)
+	}
+}
+
+// setState must be called with the lock held
+func (cb *CircuitBreaker) setState(state CircuitBreakerState) {
+	if cb.state == state {
+		return
+	}
+	transitionsTotal.WithLabelValues(cb.name, cb.state.String(), state.String()).Inc()
+	stateGauge.WithLabelValues(cb.name).Set(float64(state))
+	if len(cb.onStateChange) > 0 {
+		cb.transitions = append(cb.transitions, transition{from: cb.state, to: state})
+	}
+
+	cb.state = state
+	cb.generation++
+	cb.halfOpenRequests = 0
+	cb.halfOpenSuccesses = 0
+	switch state {
+	case StateOpen:
+		cb.lastAttempt = cb.now()
+	case StateClosed:
+		cb.window.reset()
 	}
 }
@@ -0,0 +1,279 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	errFailure  = errors.New("unavailable")
+	errBusiness = errors.New("not found")
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestCircuitBreaker(clock *fakeClock, opts ...CircuitBreakerOption) *CircuitBreaker {
+	cb := NewCircuitBreaker(append([]CircuitBreakerOption{WithName("test"), WithRetryTimeout(time.Second)}, opts...)...)
+	cb.now = clock.Now
+	return cb
+}
+
+func call(cb *CircuitBreaker, err error) error {
+	_, err = Execute(context.Background(), cb, func(ctx context.Context) (struct{}, error) { return struct{}{}, err })
+	return err
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cb := newTestCircuitBreaker(clock, WithMaxFailures(3))
+
+	call(cb, errFailure)
+	call(cb, errFailure)
+	call(cb, nil)
+	call(cb, errFailure)
+	call(cb, errFailure)
+	if cb.State() != StateClosed {
+		t.Fatalf("expected success to reset consecutive failures, got: %s", cb.State())
+	}
+	call(cb, errFailure)
+	if cb.State() != StateOpen {
+		t.Fatalf("expected breaker to open after 3 consecutive failures, got: %s", cb.State())
+	}
+	if err := call(cb, nil); !errors.Is(err, ErrOpen) {
+		t.Errorf("expected open breaker to reject calls, got: %v", err)
+	}
+}
+
+func TestCountWindow(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cb := newTestCircuitBreaker(clock, WithCountWindow(10, 0.5, 4))
+
+	// below minimum requests
+	for i := 0; i < 3; i++ {
+		call(cb, errFailure)
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("expected breaker to stay closed below minimum requests, got: %s", cb.State())
+	}
+
+	// 3 failures of 9 calls
+	for i := 0; i < 6; i++ {
+		call(cb, nil)
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("expected breaker to stay closed below failure rate, got: %s", cb.State())
+	}
+
+	// oldest failures are evicted, 5 failures of the last 10 calls trips the breaker
+	for i := 0; i < 4; i++ {
+		call(cb, errFailure)
+		if cb.State() != StateClosed {
+			t.Fatalf("expected breaker to stay closed after %d more failures, got: %s", i+1, cb.State())
+		}
+	}
+	call(cb, errFailure)
+	if cb.State() != StateOpen {
+		t.Fatalf("expected breaker to open at failure rate, got: %s", cb.State())
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cb := newTestCircuitBreaker(clock, WithTimeWindow(5*time.Second, 0.5, 4))
+
+	call(cb, errFailure)
+	call(cb, errFailure)
+	call(cb, errFailure)
+
+	// failures older than the window are dropped
+	clock.Advance(6 * time.Second)
+	call(cb, errFailure)
+	call(cb, nil)
+	call(cb, nil)
+	if cb.State() != StateClosed {
+		t.Fatalf("expected expired failures not to count, got: %s", cb.State())
+	}
+
+	clock.Advance(2 * time.Second)
+	call(cb, errFailure)
+	if cb.State() != StateOpen {
+		t.Fatalf("expected breaker to open at failure rate within window, got: %s", cb.State())
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cb := newTestCircuitBreaker(clock,
+		WithMaxFailures(2),
+		WithIsFailure(func(err error) bool { return !errors.Is(err, errBusiness) }),
+	)
+
+	for i := 0; i < 5; i++ {
+		if err := call(cb, errBusiness); !errors.Is(err, errBusiness) {
+			t.Fatalf("expected business error to be returned, got: %v", err)
+		}
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("expected business errors not to trip the breaker, got: %s", cb.State())
+	}
+
+	call(cb, errFailure)
+	call(cb, errFailure)
+	if cb.State() != StateOpen {
+		t.Fatalf("expected failures to trip the breaker, got: %s", cb.State())
+	}
+}
+
+func TestHalfOpenProbes(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cb := newTestCircuitBreaker(clock, WithMaxFailures(1), WithHalfOpenMaxRequests(2))
+
+	call(cb, errFailure)
+	clock.Advance(time.Second)
+	if cb.State() != StateHalfOpen {
+		t.Fatalf("expected breaker to be half open after retry timeout, got: %s", cb.State())
+	}
+
+	// probes run concurrently, requests beyond the probe limit are rejected
+	release := make(chan struct{})
+	admitted := make(chan struct{}, 2)
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := Execute(context.Background(), cb, func(ctx context.Context) (int, error) {
+				admitted <- struct{}{}
+				<-release
+				return 1, nil
+			})
+			results <- err
+		}()
+	}
+	<-admitted
+	<-admitted
+	if err := call(cb, nil); !errors.Is(err, ErrOpen) {
+		t.Errorf("expected requests beyond probe limit to be rejected, got: %v", err)
+	}
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("expected probe to succeed, got: %v", err)
+		}
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("expected breaker to close after all probes succeed, got: %s", cb.State())
+	}
+
+	// a failing probe opens the breaker again
+	call(cb, errFailure)
+	clock.Advance(time.Second)
+	call(cb, nil)
+	call(cb, errFailure)
+	if cb.State() != StateOpen {
+		t.Fatalf("expected failed probe to open the breaker, got: %s", cb.State())
+	}
+}
+
+func TestExecuteContext(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cb := newTestCircuitBreaker(clock, WithMaxFailures(1))
+
+	result, err := Execute(context.Background(), cb, func(ctx context.Context) (string, error) { return "driver", nil })
+	if err != nil || result != "driver" {
+		t.Fatalf("expected typed result, got: %q %v", result, err)
+	}
+
+	// done context is not called
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	if _, err := Execute(ctx, cb, func(ctx context.Context) (string, error) {
+		called = true
+		return "", nil
+	}); !errors.Is(err, context.Canceled) || called {
+		t.Errorf("expected canceled context to be returned without calling fn, got: %v called: %v", err, called)
+	}
+
+	// calls canceled by the caller are not failures
+	ctx, cancel = context.WithCancel(context.Background())
+	if _, err := Execute(ctx, cb, func(ctx context.Context) (string, error) {
+		cancel()
+		return "", ctx.Err()
+	}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled error, got: %v", err)
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("expected canceled call not to trip the breaker, got: %s", cb.State())
+	}
+
+	// calls running past the deadline are failures
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := Execute(ctx, cb, func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded error, got: %v", err)
+	}
+	if cb.State() != StateOpen {
+		t.Fatalf("expected call past the deadline to trip the breaker, got: %s", cb.State())
+	}
+	clock.Advance(time.Second)
+	call(cb, nil)
+
+	// canceled probes give their slot back
+	call(cb, errFailure)
+	clock.Advance(time.Second)
+	ctx, cancel = context.WithCancel(context.Background())
+	Execute(ctx, cb, func(ctx context.Context) (string, error) {
+		cancel()
+		return "", ctx.Err()
+	})
+	if err := call(cb, nil); err != nil {
+		t.Errorf("expected probe slot of canceled call to be released, got: %v", err)
+	}
+	if cb.State() != StateClosed {
+		t.Errorf("expected breaker to close, got: %s", cb.State())
+	}
+}
+
+func TestOnStateChange(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	var transitions []string
+	var cb *CircuitBreaker
+	cb = newTestCircuitBreaker(clock,
+		WithMaxFailures(1),
+		WithOnStateChange(func(name string, from, to CircuitBreakerState) {
+			// hooks run without the lock held
+			if cb.State() != to {
+				t.Errorf("expected state %s in hook, got: %s", to, cb.State())
+			}
+			transitions = append(transitions, name+":"+from.String()+"->"+to.String())
+		}),
+	)
+
+	call(cb, errFailure)
+	clock.Advance(time.Second)
+	call(cb, nil)
+
+	expected := []string{"test:closed->open", "test:open->half_open", "test:half_open->closed"}
+	if len(transitions) != len(expected) {
+		t.Fatalf("expected transitions %v, got: %v", expected, transitions)
+	}
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Errorf("expected transition %s, got: %s", expected[i], transitions[i])
+		}
+	}
+}
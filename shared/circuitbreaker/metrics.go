@@ -0,0 +1,18 @@
+package circuitbreaker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	stateGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "circuit_breaker_state",
+		Help: "Current state of the circuit breaker, 0 is closed, 1 is open and 2 is half open.",
+	}, []string{"name"})
+
+	transitionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "circuit_breaker_transitions_total",
+		Help: "Number of circuit breaker state transitions.",
+	}, []string{"name", "from", "to"})
+)
@@ -0,0 +1,144 @@
+package circuitbreaker
+
+import (
+	"time"
+)
+
+// window records the outcome of calls while the breaker is closed and decides when it trips
+type window interface {
+	record(now time.Time, failure bool)
+	shouldTrip(now time.Time) bool
+	reset()
+}
+
+// consecutiveWindow trips after maxFailures consecutive failures
+type consecutiveWindow struct {
+	maxFailures int
+	failures    int
+}
+
+func (w *consecutiveWindow) record(now time.Time, failure bool) {
+	if !failure {
+		w.failures = 0
+		return
+	}
+	w.failures++
+}
+
+func (w *consecutiveWindow) shouldTrip(now time.Time) bool {
+	return w.failures >= w.maxFailures
+}
+
+func (w *consecutiveWindow) reset() {
+	w.failures = 0
+}
+
+// rateThreshold trips once at least minRequests calls are recorded and failureRate of them failed
+type rateThreshold struct {
+	failureRate float64
+	minRequests int
+}
+
+func (t rateThreshold) exceeded(total, failures int) bool {
+	if total == 0 || total < t.minRequests {
+		return false
+	}
+	return float64(failures)/float64(total) >= t.failureRate
+}
+
+// countWindow keeps the outcomes of the last size calls
+type countWindow struct {
+	rateThreshold
+	outcomes []bool
+	next     int
+	total    int
+	failures int
+}
+
+func newCountWindow(size int, threshold rateThreshold) *countWindow {
+	return &countWindow{
+		rateThreshold: threshold,
+		outcomes:      make([]bool, size),
+	}
+}
+
+func (w *countWindow) record(now time.Time, failure bool) {
+	if w.total == len(w.outcomes) {
+		// evict the oldest outcome
+		if w.outcomes[w.next] {
+			w.failures--
+		}
+	} else {
+		w.total++
+	}
+	w.outcomes[w.next] = failure
+	if failure {
+		w.failures++
+	}
+	w.next = (w.next + 1) % len(w.outcomes)
+}
+
+func (w *countWindow) shouldTrip(now time.Time) bool {
+	return w.exceeded(w.total, w.failures)
+}
+
+func (w *countWindow) reset() {
+	clear(w.outcomes)
+	w.next, w.total, w.failures = 0, 0, 0
+}
+
+// timeBucket aggregates the calls made within one bucket interval
+type timeBucket struct {
+	start    time.Time
+	total    int
+	failures int
+}
+
+// timeWindow keeps the outcomes of calls made within the last duration, split into one second buckets
+type timeWindow struct {
+	rateThreshold
+	bucketSize time.Duration
+	buckets    []timeBucket
+}
+
+func newTimeWindow(duration time.Duration, threshold rateThreshold) *timeWindow {
+	bucketSize := time.Second
+	if duration < bucketSize {
+		bucketSize = duration
+	}
+	count := int((duration + bucketSize - 1) / bucketSize)
+	return &timeWindow{
+		rateThreshold: threshold,
+		bucketSize:    bucketSize,
+		buckets:       make([]timeBucket, count),
+	}
+}
+
+func (w *timeWindow) record(now time.Time, failure bool) {
+	start := now.Truncate(w.bucketSize)
+	bucket := &w.buckets[int(start.UnixNano()/int64(w.bucketSize))%len(w.buckets)]
+	if !bucket.start.Equal(start) {
+		*bucket = timeBucket{start: start}
+	}
+	bucket.total++
+	if failure {
+		bucket.failures++
+	}
+}
+
+func (w *timeWindow) shouldTrip(now time.Time) bool {
+	var total, failures int
+	oldest := now.Truncate(w.bucketSize).Add(-time.Duration(len(w.buckets)-1) * w.bucketSize)
+	for _, bucket := range w.buckets {
+		if bucket.start.Before(oldest) {
+			continue
+		}
+		total += bucket.total
+		failures += bucket.failures
+	}
+	return w.exceeded(total, failures)
+}
+
+func (w *timeWindow) reset() {
+	clear(w.buckets)
+}
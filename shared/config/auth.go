@@ -0,0 +1,93 @@
+package config
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/viper"
+)
+
+func GetAuthJwtAlgorithm() string {
+	return viper.GetString("auth.jwt.algorithm")
+}
+
+func GetAuthJwtSecret() string {
+	return viper.GetString("auth.jwt.secret")
+}
+
+// GetAuthJwtSecretFile returns the file the hmac secret is read from if no secret is set, e.g. a mounted secret
+func GetAuthJwtSecretFile() string {
+	return viper.GetString("auth.jwt.secretFile")
+}
+
+func GetAuthJwtPublicKeyFile() string {
+	return viper.GetString("auth.jwt.publicKeyFile")
+}
+
+func GetAuthJwtJWKSUrl() string {
+	return viper.GetString("auth.jwt.jwksUrl")
+}
+
+func GetAuthJwtJWKSFile() string {
+	return viper.GetString("auth.jwt.jwksFile")
+}
+
+func GetAuthJwtJWKSRefreshInterval() int {
+	return viper.GetInt("auth.jwt.jwksRefreshInterval")
+}
+
+func GetAuthJwtIssuer() string {
+	return viper.GetString("auth.jwt.issuer")
+}
+
+func GetAuthJwtAudience() string {
+	return viper.GetString("auth.jwt.audience")
+}
+
+func GetAuthJwtLeeway() int {
+	return viper.GetInt("auth.jwt.leeway")
+}
+
+func GetAuthJwtPrivateKeyFile() string {
+	return viper.GetString("auth.jwt.privateKeyFile")
+}
+
+func GetAuthJwtKeyID() string {
+	return viper.GetString("auth.jwt.keyId")
+}
+
+func GetAuthAccessTokenTTL() int {
+	return viper.GetInt("auth.accessTokenTTL")
+}
+
+func GetAuthRefreshTokenTTL() int {
+	return viper.GetInt("auth.refreshTokenTTL")
+}
+
+type AuthClient struct {
+	ID string `mapstructure:"id" json:"id"`
+	// SecretHash is the bcrypt hash of the client secret
+	SecretHash string `mapstructure:"secretHash" json:"secretHash"`
+	// Scopes are granted to the tokens issued to the client, e.g. rider, driver, ops or service
+	Scopes []string `mapstructure:"scopes" json:"scopes"`
+}
+
+// GetAuthClients returns the clients allowed to request tokens, the AUTH_CLIENTS environment variable
+// overrides them with a json array so that client secrets are supplied at deploy time
+func GetAuthClients() ([]AuthClient, error) {
+	var clients []AuthClient
+	if raw, ok := viper.Get("auth.clients").(string); ok {
+		if err := json.Unmarshal([]byte(raw), &clients); err != nil {
+			return nil, err
+		}
+		return clients, nil
+	}
+	if err := viper.UnmarshalKey("auth.clients", &clients); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// GetAuthMTLSAllowedIdentities returns the client certificate common names or dns names accepted as services
+func GetAuthMTLSAllowedIdentities() []string {
+	return viper.GetStringSlice("auth.mtls.allowedIdentities")
+}
@@ -9,3 +9,30 @@ func GetCircuitBreakerRetryTimeout() int {
 func GetCircuitBreakerMaxFailures() int {
 	return viper.GetInt("circuitBreaker.maxFailures")
 }
+
+// GetCircuitBreakerWindow returns the failure window type, consecutive, count or time
+func GetCircuitBreakerWindow() string {
+	return viper.GetString("circuitBreaker.window")
+}
+
+// GetCircuitBreakerWindowSize returns the number of calls kept by the count window
+func GetCircuitBreakerWindowSize() int {
+	return viper.GetInt("circuitBreaker.windowSize")
+}
+
+// GetCircuitBreakerWindowDuration returns the seconds of calls kept by the time window
+func GetCircuitBreakerWindowDuration() int {
+	return viper.GetInt("circuitBreaker.windowDuration")
+}
+
+func GetCircuitBreakerFailureRate() float64 {
+	return viper.GetFloat64("circuitBreaker.failureRate")
+}
+
+func GetCircuitBreakerMinimumRequests() int {
+	return viper.GetInt("circuitBreaker.minimumRequests")
+}
+
+func GetCircuitBreakerHalfOpenMaxRequests() int {
+	return viper.GetInt("circuitBreaker.halfOpenMaxRequests")
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/spf13/viper"
 )
 
@@ -10,8 +12,12 @@ func SetDefaults(defaults map[string]string) {
 	}
 }
 
+// Init reads the config file, keys are overridden by environment variables of the upper cased key
+// with dots replaced by underscores, e.g. REMOTE_DRIVERLOCATIONAPI_TOKEN
 func Init(file string) error {
 	viper.SetConfigFile(file)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
 	viper.WatchConfig()
 
 	return viper.ReadInConfig()
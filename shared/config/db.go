@@ -2,6 +2,10 @@ package config
 
 import "github.com/spf13/viper"
 
+func GetDBDriver() string {
+	return viper.GetString("db.driver")
+}
+
 func GetDBName() string {
 	return viper.GetString("db.name")
 }
@@ -9,3 +13,11 @@ func GetDBName() string {
 func GetDBConnectionString() string {
 	return viper.GetString("db.connectionString")
 }
+
+func GetDBLocationTTL() int {
+	return viper.GetInt("db.locationTTL")
+}
+
+func GetDBHistoryRetention() int {
+	return viper.GetInt("db.historyRetention")
+}
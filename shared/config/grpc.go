@@ -0,0 +1,14 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+func GetGrpcServerAddress() string {
+	return fmt.Sprintf("%s:%d",
+		viper.GetString("grpc.ipAddress"),
+		viper.GetInt("grpc.port"),
+	)
+}
@@ -0,0 +1,15 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// GetHealthReadinessTimeout returns the time in seconds all readiness checks may take
+func GetHealthReadinessTimeout() int {
+	return viper.GetInt("health.readinessTimeout")
+}
+
+// GetHealthCheckInterval returns the interval in seconds readiness checks of the grpc health service are run in
+func GetHealthCheckInterval() int {
+	return viper.GetInt("health.checkInterval")
+}
@@ -0,0 +1,14 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+func GetMetricsServerAddress() string {
+	return fmt.Sprintf("%s:%d",
+		viper.GetString("metrics.ipAddress"),
+		viper.GetInt("metrics.port"),
+	)
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+type RateLimit struct {
+	// Rate is the number of requests per second a client can make
+	Rate float64 `mapstructure:"rate"`
+	// Burst is the number of requests a client can make at once
+	Burst int `mapstructure:"burst"`
+}
+
+func IsRateLimitEnabled() bool {
+	return viper.GetBool("rateLimit.enabled")
+}
+
+// GetRateLimitDefault returns the limit of routes without their own limit
+func GetRateLimitDefault() RateLimit {
+	return RateLimit{
+		Rate:  viper.GetFloat64("rateLimit.rate"),
+		Burst: viper.GetInt("rateLimit.burst"),
+	}
+}
+
+// GetRateLimitRoutes returns the limits by route name, names are lowercased by viper
+func GetRateLimitRoutes() (map[string]RateLimit, error) {
+	var routes map[string]RateLimit
+	if err := viper.UnmarshalKey("rateLimit.routes", &routes); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
@@ -13,3 +13,100 @@ func GetRemoteUrl(serviceName string) string {
 func GetRemoteVersion(serviceName string) string {
 	return viper.GetString(fmt.Sprintf("remote.%s.version", serviceName))
 }
+
+// GetRemoteProtocol returns the protocol used to call the service, http or grpc
+func GetRemoteProtocol(serviceName string) string {
+	return viper.GetString(fmt.Sprintf("remote.%s.protocol", serviceName))
+}
+
+func GetRemoteGrpcAddress(serviceName string) string {
+	return viper.GetString(fmt.Sprintf("remote.%s.grpcAddress", serviceName))
+}
+
+// GetRemoteToken returns the bearer token sent with every request to the service
+func GetRemoteToken(serviceName string) string {
+	return viper.GetString(fmt.Sprintf("remote.%s.token", serviceName))
+}
+
+// GetRemoteTokenFile returns the file the bearer token is read from if no token is set, e.g. a mounted secret
+func GetRemoteTokenFile(serviceName string) string {
+	return viper.GetString(fmt.Sprintf("remote.%s.tokenFile", serviceName))
+}
+
+func GetRemoteTLSCertFile(serviceName string) string {
+	return viper.GetString(fmt.Sprintf("remote.%s.tls.certFile", serviceName))
+}
+
+func GetRemoteTLSKeyFile(serviceName string) string {
+	return viper.GetString(fmt.Sprintf("remote.%s.tls.keyFile", serviceName))
+}
+
+func GetRemoteTLSCAFile(serviceName string) string {
+	return viper.GetString(fmt.Sprintf("remote.%s.tls.caFile", serviceName))
+}
+
+// GetRemoteRetryMaxAttempts returns the number of attempts of a request including the first one
+func GetRemoteRetryMaxAttempts(serviceName string) int {
+	return viper.GetInt(fmt.Sprintf("remote.%s.retry.maxAttempts", serviceName))
+}
+
+// GetRemoteRetryInitialBackoffInMs returns the wait before the first retry
+func GetRemoteRetryInitialBackoffInMs(serviceName string) int {
+	return viper.GetInt(fmt.Sprintf("remote.%s.retry.initialBackoff", serviceName))
+}
+
+func GetRemoteRetryMaxBackoffInMs(serviceName string) int {
+	return viper.GetInt(fmt.Sprintf("remote.%s.retry.maxBackoff", serviceName))
+}
+
+func GetRemoteRetryMultiplier(serviceName string) float64 {
+	return viper.GetFloat64(fmt.Sprintf("remote.%s.retry.multiplier", serviceName))
+}
+
+// GetRemoteRetryJitter returns the randomized fraction of the backoff
+func GetRemoteRetryJitter(serviceName string) float64 {
+	return viper.GetFloat64(fmt.Sprintf("remote.%s.retry.jitter", serviceName))
+}
+
+// GetRemoteUrls returns the base urls of the replicas of the service
+func GetRemoteUrls(serviceName string) []string {
+	return viper.GetStringSlice(fmt.Sprintf("remote.%s.urls", serviceName))
+}
+
+// GetRemoteSRV returns the dns srv name resolving the replicas of the service
+func GetRemoteSRV(serviceName string) string {
+	return viper.GetString(fmt.Sprintf("remote.%s.srv", serviceName))
+}
+
+// GetRemoteBalancerStrategy returns the strategy picking the replica of a request
+func GetRemoteBalancerStrategy(serviceName string) string {
+	return viper.GetString(fmt.Sprintf("remote.%s.balancer.strategy", serviceName))
+}
+
+// GetRemoteResolveInterval returns the interval in seconds the srv name of the service is resolved in
+func GetRemoteResolveInterval(serviceName string) int {
+	return viper.GetInt(fmt.Sprintf("remote.%s.balancer.resolveInterval", serviceName))
+}
+
+// GetRemoteHealthCheckInterval returns the interval in seconds the replicas are health checked in
+func GetRemoteHealthCheckInterval(serviceName string) int {
+	return viper.GetInt(fmt.Sprintf("remote.%s.balancer.healthCheckInterval", serviceName))
+}
+
+// IsRemoteHedgingEnabled reports whether slow requests to the service are hedged on another replica
+func IsRemoteHedgingEnabled(serviceName string) bool {
+	return viper.GetBool(fmt.Sprintf("remote.%s.hedging.enabled", serviceName))
+}
+
+// GetRemoteHedgingPercentile returns the percentile of recent latencies a request may take before it is hedged
+func GetRemoteHedgingPercentile(serviceName string) float64 {
+	return viper.GetFloat64(fmt.Sprintf("remote.%s.hedging.percentile", serviceName))
+}
+
+func GetRemoteHedgingMinDelayInMs(serviceName string) int {
+	return viper.GetInt(fmt.Sprintf("remote.%s.hedging.minDelay", serviceName))
+}
+
+func GetRemoteHedgingMaxDelayInMs(serviceName string) int {
+	return viper.GetInt(fmt.Sprintf("remote.%s.hedging.maxDelay", serviceName))
+}
@@ -0,0 +1,32 @@
+package config
+
+import "github.com/spf13/viper"
+
+func GetStreamPingInterval() int {
+	return viper.GetInt("stream.pingInterval")
+}
+
+func GetStreamPongTimeout() int {
+	return viper.GetInt("stream.pongTimeout")
+}
+
+func GetStreamMaxMessageSize() int {
+	return viper.GetInt("stream.maxMessageSize")
+}
+
+func GetStreamFlushIntervalInMs() int {
+	return viper.GetInt("stream.flushIntervalMs")
+}
+
+func GetStreamBatchSize() int {
+	return viper.GetInt("stream.batchSize")
+}
+
+func GetStreamMaxPending() int {
+	return viper.GetInt("stream.maxPending")
+}
+
+// GetStreamMaxFlushRetries returns how many times a failed batch is requeued before it is dropped
+func GetStreamMaxFlushRetries() int {
+	return viper.GetInt("stream.maxFlushRetries")
+}
@@ -0,0 +1,18 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+func GetTLSCertFile() string {
+	return viper.GetString("tls.certFile")
+}
+
+func GetTLSKeyFile() string {
+	return viper.GetString("tls.keyFile")
+}
+
+// GetTLSClientCAFile returns the ca bundle that client certificates are verified against
+func GetTLSClientCAFile() string {
+	return viper.GetString("tls.clientCAFile")
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+func IsTracingEnabled() bool {
+	return viper.GetBool("tracing.enabled")
+}
+
+// GetTracingEndpoint returns the host:port of the otlp grpc receiver
+func GetTracingEndpoint() string {
+	return viper.GetString("tracing.endpoint")
+}
+
+func IsTracingInsecure() bool {
+	return viper.GetBool("tracing.insecure")
+}
+
+func GetTracingSampleRatio() float64 {
+	return viper.GetFloat64("tracing.sampleRatio")
+}
@@ -8,6 +8,7 @@ import (
 var (
 	errInternal       = errors.New("internal error")
 	errEntityNotFound = errors.New("entity not found")
+	errUnauthorized   = errors.New("unauthorized")
 )
 
 func ErrEntityNotFound(entity string) error {
@@ -31,3 +32,14 @@ func ErrInternal(inner error) error {
 func IsInternalErr(err error) bool {
 	return errors.Is(err, errInternal)
 }
+
+func ErrUnauthorized(inner error) error {
+	if inner == nil {
+		return errUnauthorized
+	}
+	return fmt.Errorf("%w: %w", errUnauthorized, inner)
+}
+
+func IsUnauthorizedErr(err error) bool {
+	return errors.Is(err, errUnauthorized)
+}
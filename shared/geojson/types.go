@@ -1,5 +1,7 @@
 package geojson
 
+import "math"
+
 const (
 	TypePoint              = "Point"
 	TypeLineString         = "LineString"
@@ -14,6 +16,11 @@ const (
 // Coordinate represents a single coordinate pair (longitude, latitude).
 type Coordinate []float64
 
+// IsValid reports whether the coordinate is a pair of finite numbers
+func (c Coordinate) IsValid() bool {
+	return len(c) == 2 && !math.IsNaN(c[0]) && !math.IsInf(c[0], 0) && !math.IsNaN(c[1]) && !math.IsInf(c[1], 0)
+}
+
 // Coordinates represents a set of Coordinates
 type Coordinates []Coordinate
 
@@ -69,14 +76,24 @@ func (p Polygon) IsValid() bool {
 		return false
 	}
 	for _, ring := range p.Coordinates {
-		if len(ring) < 4 {
+		if !isValidRing(ring) {
 			return false
 		}
-		if ring[0][0] != ring[len(ring)-1][0] || ring[0][1] != ring[len(ring)-1][1] {
+	}
+	return true
+}
+
+// isValidRing reports whether ring is a closed linear ring of at least 4 valid positions
+func isValidRing(ring Coordinates) bool {
+	if len(ring) < 4 {
+		return false
+	}
+	for _, position := range ring {
+		if !position.IsValid() {
 			return false
 		}
 	}
-	return true
+	return ring[0][0] == ring[len(ring)-1][0] && ring[0][1] == ring[len(ring)-1][1]
 }
 
 // MultiPoint represents a GeoJSON MultiPoint geometry.
@@ -134,14 +151,10 @@ func (mp MultiPolygon) IsValid() bool {
 			return false
 		}
 		for _, ring := range polygon {
-			if len(ring) < 4 {
-				return false
-			}
-			if ring[0][0] != ring[len(ring)-1][0] || ring[0][1] != ring[len(ring)-1][1] {
+			if !isValidRing(ring) {
 				return false
 			}
 		}
-
 	}
 	return true
 }
@@ -0,0 +1,47 @@
+package httpfiber
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// disconnectPollInterval is how often the connection of a request is checked for being closed by the client
+const disconnectPollInterval = 100 * time.Millisecond
+
+// ErrClientDisconnected is the cause of user contexts canceled because the client closed its connection
+var ErrClientDisconnected = errors.New("client disconnected")
+
+// watchDisconnect cancels the request with ErrClientDisconnected once its client closes conn, the returned
+// function stops watching and returns after the connection is no longer touched.
+// Connections whose socket can not be peeked, e.g. in memory connections of tests, are not watched
+func watchDisconnect(conn net.Conn, cancel context.CancelCauseFunc) func() {
+	if _, ok := peekClosed(conn); !ok {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(disconnectPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if closed, _ := peekClosed(conn); closed {
+					cancel(ErrClientDisconnected)
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package httpfiber
+
+import "net"
+
+// peekClosed is not supported on this platform, disconnected clients are not detected
+func peekClosed(conn net.Conn) (closed bool, ok bool) {
+	return false, false
+}
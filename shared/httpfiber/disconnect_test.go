@@ -0,0 +1,53 @@
+//go:build linux || darwin
+
+package httpfiber
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestDeadlineMiddlewareClientDisconnect(t *testing.T) {
+	causes := make(chan error, 1)
+	app := fiber.New()
+	app.Use(TracingMiddleware)
+	app.Use(DeadlineMiddleware(10 * time.Second))
+	app.Get("/", func(ctx fiber.Ctx) error {
+		<-ctx.UserContext().Done()
+		causes <- context.Cause(ctx.UserContext())
+		return ctx.SendStatus(http.StatusOK)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	go app.Listener(ln, fiber.ListenConfig{DisableStartupMessage: true})
+	t.Cleanup(func() { ln.Close() })
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("could not connect: %v", err)
+	}
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")); err != nil {
+		t.Fatalf("could not send request: %v", err)
+	}
+	// give the server time to start handling the request before the client gives up
+	time.Sleep(2 * disconnectPollInterval)
+	conn.Close()
+
+	select {
+	case cause := <-causes:
+		if !errors.Is(cause, ErrClientDisconnected) {
+			t.Errorf("expected user context to be canceled by the disconnect, got: %v", cause)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected user context to be canceled once the client disconnects")
+	}
+}
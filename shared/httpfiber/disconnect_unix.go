@@ -0,0 +1,47 @@
+//go:build linux || darwin
+
+package httpfiber
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"syscall"
+)
+
+// peekClosed reports whether the client closed conn, the socket is peeked without blocking so that no data
+// is consumed. ok is false if conn does not expose its socket. Pending data, e.g. a pipelined request or a tls
+// close notification, is reported as open
+func peekClosed(conn net.Conn) (closed bool, ok bool) {
+	if tlsConn, isTLS := conn.(*tls.Conn); isTLS {
+		conn = tlsConn.NetConn()
+	}
+	sysConn, isSysConn := conn.(syscall.Conn)
+	if !isSysConn {
+		return false, false
+	}
+	rawConn, err := sysConn.SyscallConn()
+	if err != nil {
+		return false, false
+	}
+
+	var n int
+	var peekErr error
+	err = rawConn.Read(func(fd uintptr) bool {
+		var buf [1]byte
+		n, _, peekErr = syscall.Recvfrom(int(fd), buf[:], syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		return true
+	})
+	switch {
+	case err != nil:
+		// the connection is closed by the server
+		return true, true
+	case errors.Is(peekErr, syscall.EAGAIN), errors.Is(peekErr, syscall.EWOULDBLOCK), errors.Is(peekErr, syscall.EINTR):
+		return false, true
+	case peekErr != nil:
+		return true, true
+	default:
+		// a read of zero bytes is the end of the stream
+		return n == 0, true
+	}
+}
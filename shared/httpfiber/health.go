@@ -0,0 +1,72 @@
+package httpfiber
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/shared/response"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+// HealthCheck returns an error if the dependency it checks is not ready
+type HealthCheck func(ctx context.Context) error
+
+// LivenessHandler reports that the process is up and serving requests
+func LivenessHandler(ctx fiber.Ctx) error {
+	return response.Success(ctx, nil)
+}
+
+// ReadinessHandler runs the checks concurrently within timeout and responds with the status of each check,
+// 503 is returned if any of them fails. Errors are logged instead of being exposed
+func ReadinessHandler(logger *zap.Logger, timeout time.Duration, checks map[string]HealthCheck) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		logger := CtxLogger(ctx, logger)
+
+		failed := RunHealthChecks(ctx.UserContext(), timeout, checks)
+		statuses := make(map[string]string, len(checks))
+		for name := range checks {
+			statuses[name] = HealthStatusUp
+		}
+		for name, err := range failed {
+			logger.Warn("readiness check failed", zap.String("check", name), zap.Error(err))
+			statuses[name] = HealthStatusDown
+		}
+
+		if len(failed) > 0 {
+			return response.FailWithData(ctx, response.ErrCodeNotReady, response.ErrMsgNotReady, http.StatusServiceUnavailable, statuses)
+		}
+		return response.Success(ctx, statuses)
+	}
+}
+
+// RunHealthChecks runs the checks concurrently within timeout and returns the errors of the failed checks by name
+func RunHealthChecks(ctx context.Context, timeout time.Duration, checks map[string]HealthCheck) map[string]error {
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failed := make(map[string]error)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+			if err := check(checkCtx); err != nil {
+				mu.Lock()
+				failed[name] = err
+				mu.Unlock()
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return failed
+}
@@ -0,0 +1,76 @@
+package httpfiber
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/shared/response"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("unreachable") }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	testCases := []struct {
+		name           string
+		checks         map[string]HealthCheck
+		expectedStatus int
+		expected       map[string]string
+	}{
+		{
+			name:           "should be ready if all checks pass",
+			checks:         map[string]HealthCheck{"database": up, "import": up},
+			expectedStatus: http.StatusOK,
+			expected:       map[string]string{"database": HealthStatusUp, "import": HealthStatusUp},
+		},
+		{
+			name:           "should not be ready if a check fails",
+			checks:         map[string]HealthCheck{"database": up, "import": down},
+			expectedStatus: http.StatusServiceUnavailable,
+			expected:       map[string]string{"database": HealthStatusUp, "import": HealthStatusDown},
+		},
+		{
+			name:           "should not be ready if a check times out",
+			checks:         map[string]HealthCheck{"database": slow},
+			expectedStatus: http.StatusServiceUnavailable,
+			expected:       map[string]string{"database": HealthStatusDown},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/readyz", ReadinessHandler(zap.NewNop(), 50*time.Millisecond, tc.checks))
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected status %d, got: %d", tc.expectedStatus, resp.StatusCode)
+			}
+			statuses := map[string]string{}
+			if err := json.NewDecoder(resp.Body).Decode(&response.Response{Data: &statuses}); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			for name, status := range tc.expected {
+				if statuses[name] != status {
+					t.Errorf("expected %s to be %s, got: %s", name, status, statuses[name])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package httpfiber
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of http requests by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of http requests by route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// MetricsMiddleware records the count and latency of http requests, requests are labeled
+// by the route pattern rather than the path to keep the number of series bounded
+func MetricsMiddleware(ctx fiber.Ctx) error {
+	start := time.Now()
+
+	err := ctx.Next()
+
+	// errors returned to fiber are written by the error handler after the middleware returns
+	status := ctx.Response().StatusCode()
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			status = fiberErr.Code
+		} else {
+			status = fiber.StatusInternalServerError
+		}
+	}
+
+	labels := prometheus.Labels{
+		"method": ctx.Method(),
+		"route":  ctx.Route().Path,
+		"status": strconv.Itoa(status),
+	}
+	httpRequestsTotal.With(labels).Inc()
+	httpRequestDuration.With(labels).Observe(time.Since(start).Seconds())
+
+	return err
+}
+
+// MetricsHandler serves the metrics of the default prometheus registry
+func MetricsHandler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
+
+// MetricsServer serves /metrics apart from the api, it is meant to listen on an internal address
+// that is reachable by prometheus but not published to clients
+type MetricsServer struct {
+	app *fiber.App
+}
+
+func NewMetricsServer() *MetricsServer {
+	app := fiber.New()
+	app.Get("/metrics", MetricsHandler())
+	return &MetricsServer{app: app}
+}
+
+func (ms *MetricsServer) Listen(address string) error {
+	return ms.app.Listen(address, fiber.ListenConfig{
+		DisableStartupMessage: true,
+	})
+}
+
+func (ms *MetricsServer) Shutdown() error {
+	return ms.app.Shutdown()
+}
@@ -0,0 +1,45 @@
+package httpfiber
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(MetricsMiddleware)
+	app.Get("/drivers/:id", func(ctx fiber.Ctx) error { return ctx.SendStatus(http.StatusOK) })
+
+	for _, path := range []string{"/drivers/1", "/drivers/2", "/unknown"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if count := testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodGet, "/drivers/:id", "200")); count != 2 {
+		t.Errorf("expected 2 requests labeled by route pattern, got: %v", count)
+	}
+	if count := testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodGet, "/unknown", "404")); count != 0 {
+		t.Errorf("expected unmatched paths not to be used as route label, got: %v", count)
+	}
+}
+
+func TestMetricsServer(t *testing.T) {
+	ms := NewMetricsServer()
+
+	resp, err := ms.app.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status code: %d, got: %d", http.StatusOK, resp.StatusCode)
+	}
+}
@@ -1,11 +1,18 @@
 package httpfiber
 
 import (
+	"context"
+	"net/http"
 	"time"
 
 	"github.com/aniladanir/bitaksi-casestudy/shared/log"
+	"github.com/aniladanir/bitaksi-casestudy/shared/response"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -37,20 +44,92 @@ func AccessLogMiddleware(logger *zap.Logger) fiber.Handler {
 	}
 }
 
-// SetLogger middleware adds logger with trace abilities to the request's context
+// TracingMiddleware continues the w3c trace of the caller or starts a new one, the span is stored in the
+// user context of the request. The trace-id and request-id are generated if the caller did not send them
 func TracingMiddleware(ctx fiber.Ctx) error {
 	log.Info("TracingMiddleware")
-	if ctx.Get(HeaderXTraceID) == "" {
-		traceID := uuid.NewString()
-		ctx.Set(HeaderXTraceID, traceID)
+	parent := otel.GetTextMapPropagator().Extract(ctx.Context(), headerCarrier{header: &ctx.Request().Header})
+	spanCtx, span := otel.Tracer(tracerName).Start(parent, "HTTP "+ctx.Method(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", ctx.Method()),
+			attribute.String("url.path", ctx.Path()),
+		),
+	)
+	defer span.End()
+
+	// the otel trace id is used so that logs can be correlated with traces
+	traceID := ctx.Get(HeaderXTraceID)
+	if traceID == "" {
+		traceID = uuid.NewString()
+		if sc := span.SpanContext(); sc.HasTraceID() {
+			traceID = sc.TraceID().String()
+		}
+		ctx.Request().Header.Set(HeaderXTraceID, traceID)
 	}
-	if ctx.Get(HeaderXRequestID) == "" {
-		requestID := uuid.NewString()
-		ctx.Set(HeaderXRequestID, requestID)
+	requestID := ctx.Get(HeaderXRequestID)
+	if requestID == "" {
+		requestID = uuid.NewString()
+		ctx.Request().Header.Set(HeaderXRequestID, requestID)
 	}
+	ctx.Set(HeaderXTraceID, traceID)
+	ctx.Set(HeaderXRequestID, requestID)
+
 	// store trace-id and request-id in request context
 	ctx.Context().SetUserValue(CtxKeyTraceID, ctx.GetRespHeader(HeaderXTraceID))
 	ctx.Context().SetUserValue(CtxKeyRequestID, ctx.GetRespHeader(HeaderXRequestID))
+	ctx.SetUserContext(spanCtx)
+
+	err := ctx.Next()
+
+	statusCode := ctx.Response().StatusCode()
+	span.SetName(ctx.Method() + " " + ctx.Route().Path)
+	span.SetAttributes(
+		attribute.String("http.route", ctx.Route().Path),
+		attribute.Int("http.response.status_code", statusCode),
+	)
+	if err != nil {
+		span.RecordError(err)
+	}
+	if err != nil || statusCode >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(statusCode))
+	}
+
+	return err
+}
 
-	return ctx.Next()
+// DeadlineMiddleware bounds the user context of the request with timeout so that calls made while handling it
+// are canceled once the response could no longer be written. The context is also canceled with ErrClientDisconnected
+// once the client closes its connection, and when the handler returns.
+// It must be applied after TracingMiddleware, which replaces the user context
+func DeadlineMiddleware(timeout time.Duration) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		userCtx := ctx.UserContext()
+		if timeout > 0 {
+			var cancelTimeout context.CancelFunc
+			userCtx, cancelTimeout = context.WithTimeout(userCtx, timeout)
+			defer cancelTimeout()
+		}
+		userCtx, cancel := context.WithCancelCause(userCtx)
+		defer cancel(nil)
+		ctx.SetUserContext(userCtx)
+
+		stopWatching := watchDisconnect(ctx.Context().Conn(), cancel)
+		defer stopWatching()
+
+		return ctx.Next()
+	}
+}
+
+// RecoverMiddleware recovers from panics of the handlers, the panic is logged and the request fails with an internal error
+func RecoverMiddleware(logger *zap.Logger) fiber.Handler {
+	return func(ctx fiber.Ctx) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				CtxLogger(ctx, logger).Error("recovered from panic", zap.Any("panic", r), zap.Stack("stack"))
+				err = response.Fail(ctx, response.ErrCodeInternal, response.ErrMsgInternal, http.StatusInternalServerError)
+			}
+		}()
+		return ctx.Next()
+	}
 }
@@ -0,0 +1,62 @@
+package httpfiber
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/shared/response"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+func TestDeadlineMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(TracingMiddleware)
+	app.Use(DeadlineMiddleware(time.Second))
+	app.Get("/", func(ctx fiber.Ctx) error {
+		deadline, ok := ctx.UserContext().Deadline()
+		if !ok || time.Until(deadline) > time.Second {
+			t.Errorf("expected user context deadline within timeout, got: %v %v", deadline, ok)
+		}
+		// values of the request context stay reachable
+		if ctx.UserContext().Value(CtxKeyTraceID) == nil {
+			t.Error("expected trace id in user context")
+		}
+		return ctx.SendStatus(http.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got: %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(RecoverMiddleware(zap.NewNop()))
+	app.Get("/", func(ctx fiber.Ctx) error {
+		var coordinates []float64
+		return ctx.JSON(coordinates[1])
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got: %d", http.StatusInternalServerError, resp.StatusCode)
+	}
+	var body response.Response
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("cannot decode response body: %v", err)
+	}
+	if body.Code != response.ErrCodeInternal {
+		t.Errorf("expected code: %s, got: %s", response.ErrCodeInternal, body.Code)
+	}
+}
@@ -0,0 +1,196 @@
+package httpfiber
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/shared/config"
+	"github.com/aniladanir/bitaksi-casestudy/shared/response"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// CtxKeySubject is set by authentication middlewares so that clients are rate limited by their identity
+	CtxKeySubject = "subject"
+	// CtxKeyAPIKey is set by middlewares that authenticated the api key of the request
+	CtxKeyAPIKey = "api-key"
+
+	// bucketSweepInterval is how often the memory store drops buckets that refilled completely
+	bucketSweepInterval = time.Minute
+)
+
+// RateLimit is a token bucket that refills Rate tokens per second up to Burst tokens
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitStore keeps the token buckets, implementations backed by a shared store
+// allow replicas to enforce a common limit
+type RateLimitStore interface {
+	// Take removes a token from the bucket of key, if the bucket is empty it returns
+	// false and how long to wait until a token is available
+	Take(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error)
+}
+
+// RateLimitKeyFunc identifies the client of a request
+type RateLimitKeyFunc func(ctx fiber.Ctx) string
+
+type RateLimiterConfig struct {
+	// Default is used by routes without their own limit, a zero rate disables rate limiting
+	Default RateLimit
+	// Routes are limits by route name, a zero rate disables rate limiting of the route
+	Routes map[string]RateLimit
+	// Store defaults to an in-process store
+	Store RateLimitStore
+	// KeyFunc defaults to ClientKey
+	KeyFunc RateLimitKeyFunc
+	Logger  *zap.Logger
+}
+
+type RateLimiter struct {
+	cfg RateLimiterConfig
+}
+
+func NewRateLimiter(cfg RateLimiterConfig) *RateLimiter {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryRateLimitStore()
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = ClientKey
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	return &RateLimiter{cfg: cfg}
+}
+
+// Middleware limits the requests of each client to the route, requests over the limit
+// are rejected with 429 and a Retry-After header
+func (rl *RateLimiter) Middleware(route string) fiber.Handler {
+	limit, ok := rl.cfg.Routes[route]
+	if !ok {
+		limit = rl.cfg.Default
+	}
+
+	return func(ctx fiber.Ctx) error {
+		if limit.Rate <= 0 {
+			return ctx.Next()
+		}
+
+		logger := CtxLogger(ctx, rl.cfg.Logger)
+		key := route + ":" + rl.cfg.KeyFunc(ctx)
+		allowed, retryAfter, err := rl.cfg.Store.Take(ctx.Context(), key, limit)
+		if err != nil {
+			// fail open rather than rejecting every request while the store is down
+			logger.Error("could not take rate limit token", zap.Error(err), zap.String("route", route))
+			return ctx.Next()
+		}
+		if !allowed {
+			logger.Warn("rate limit exceeded", zap.String("route", route), zap.String("key", key))
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			return response.Fail(ctx, response.ErrCodeTooManyRequests, response.ErrMsgTooManyRequests, http.StatusTooManyRequests)
+		}
+		return ctx.Next()
+	}
+}
+
+// ClientKey identifies the client by the subject or api key set by authentication or the ip address,
+// identities sent by unauthenticated clients are not trusted as they could pick a new one on every request
+func ClientKey(ctx fiber.Ctx) string {
+	if subject, ok := ctx.Locals(CtxKeySubject).(string); ok && subject != "" {
+		return "sub:" + subject
+	}
+	if apiKey, ok := ctx.Locals(CtxKeyAPIKey).(string); ok && apiKey != "" {
+		return "key:" + apiKey
+	}
+	return "ip:" + ctx.IP()
+}
+
+// NewRateLimiterFromConfig creates the rate limiter of the configured limits
+func NewRateLimiterFromConfig(logger *zap.Logger) (*RateLimiter, error) {
+	cfg := RateLimiterConfig{Logger: logger}
+	if !config.IsRateLimitEnabled() {
+		return NewRateLimiter(cfg), nil
+	}
+
+	routes, err := config.GetRateLimitRoutes()
+	if err != nil {
+		return nil, fmt.Errorf("could not read rate limit routes: %w", err)
+	}
+	defaultLimit := config.GetRateLimitDefault()
+	cfg.Default = RateLimit{Rate: defaultLimit.Rate, Burst: defaultLimit.Burst}
+	cfg.Routes = make(map[string]RateLimit, len(routes))
+	for route, limit := range routes {
+		cfg.Routes[route] = RateLimit{Rate: limit.Rate, Burst: limit.Burst}
+	}
+	return NewRateLimiter(cfg), nil
+}
+
+type tokenBucket struct {
+	limit     RateLimit
+	tokens    float64
+	updatedAt time.Time
+}
+
+// refill adds the tokens accumulated since the last update
+func (b *tokenBucket) refill(now time.Time) float64 {
+	return math.Min(float64(max(b.limit.Burst, 1)), b.tokens+now.Sub(b.updatedAt).Seconds()*b.limit.Rate)
+}
+
+// memoryRateLimitStore keeps token buckets in process, limits are not shared between replicas
+type memoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+	sweptAt time.Time
+}
+
+func NewMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+func (s *memoryRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.sweptAt) > bucketSweepInterval {
+		s.sweep(now)
+		s.sweptAt = now
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{limit: limit, tokens: float64(max(limit.Burst, 1)), updatedAt: now}
+		s.buckets[key] = bucket
+	}
+	bucket.limit = limit
+	bucket.tokens = bucket.refill(now)
+	bucket.updatedAt = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / limit.Rate * float64(time.Second))
+		return false, wait, nil
+	}
+	bucket.tokens--
+	return true, 0, nil
+}
+
+// sweep drops the buckets that refilled completely, they are recreated full on the next take
+func (s *memoryRateLimitStore) sweep(now time.Time) {
+	for key, bucket := range s.buckets {
+		if bucket.refill(now) >= float64(max(bucket.limit.Burst, 1)) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package httpfiber
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+	limit := RateLimit{Rate: 2, Burst: 3}
+
+	// burst is available at once
+	for i := 0; i < limit.Burst; i++ {
+		if allowed, _, _ := store.Take(context.Background(), "client", limit); !allowed {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+	allowed, retryAfter, _ := store.Take(context.Background(), "client", limit)
+	if allowed {
+		t.Fatal("expected request over burst to be rejected")
+	}
+	if retryAfter != 500*time.Millisecond {
+		t.Errorf("expected retry after 500ms, got: %s", retryAfter)
+	}
+
+	// other clients have their own bucket
+	if allowed, _, _ := store.Take(context.Background(), "other", limit); !allowed {
+		t.Error("expected other client to be allowed")
+	}
+
+	// tokens refill with rate
+	now = now.Add(500 * time.Millisecond)
+	if allowed, _, _ := store.Take(context.Background(), "client", limit); !allowed {
+		t.Error("expected request to be allowed after refill")
+	}
+	if allowed, _, _ := store.Take(context.Background(), "client", limit); allowed {
+		t.Error("expected request to be rejected before next refill")
+	}
+
+	// refilled buckets are swept
+	now = now.Add(2 * bucketSweepInterval)
+	store.Take(context.Background(), "other", limit)
+	if _, ok := store.buckets["client"]; ok {
+		t.Error("expected refilled bucket to be swept")
+	}
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	limiter := NewRateLimiter(RateLimiterConfig{
+		Default: RateLimit{Rate: 1, Burst: 1},
+		Routes: map[string]RateLimit{
+			"unlimited": {},
+		},
+	})
+
+	// authenticates the subject header, api keys are not authenticated
+	authenticate := func(ctx fiber.Ctx) error {
+		if subject := ctx.Get("x-subject"); subject != "" {
+			ctx.Locals(CtxKeySubject, subject)
+		}
+		return ctx.Next()
+	}
+	app := fiber.New()
+	app.Use(authenticate)
+	app.Get("/limited", func(ctx fiber.Ctx) error { return ctx.SendStatus(http.StatusOK) }, limiter.Middleware("limited"))
+	app.Get("/unlimited", func(ctx fiber.Ctx) error { return ctx.SendStatus(http.StatusOK) }, limiter.Middleware("unlimited"))
+
+	request := func(path, subject string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if subject != "" {
+			req.Header.Set("x-subject", subject)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := request("/limited", "first"); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status code: %d, got: %d", http.StatusOK, resp.StatusCode)
+	}
+	resp := request("/limited", "first")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status code: %d, got: %d", http.StatusTooManyRequests, resp.StatusCode)
+	}
+	if resp.Header.Get(fiber.HeaderRetryAfter) != "1" {
+		t.Errorf("expected retry after 1 second, got: %q", resp.Header.Get(fiber.HeaderRetryAfter))
+	}
+	if resp := request("/limited", "second"); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected other subject to be allowed, got: %d", resp.StatusCode)
+	}
+
+	// unauthenticated clients share the bucket of their ip whatever api key they send
+	for i, apiKey := range []string{"first", "second"} {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.Header.Set("x-api-key", apiKey)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if expected := []int{http.StatusOK, http.StatusTooManyRequests}[i]; resp.StatusCode != expected {
+			t.Errorf("expected status code: %d with api key %s, got: %d", expected, apiKey, resp.StatusCode)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if resp := request("/unlimited", "first"); resp.StatusCode != http.StatusOK {
+			t.Errorf("expected route without rate to be unlimited, got: %d", resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package httpfiber
+
+import (
+	"context"
+
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+)
+
+const tracerName = "github.com/aniladanir/bitaksi-casestudy/shared/httpfiber"
+
+// headerCarrier adapts fasthttp request headers to the otel propagation carrier
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (c headerCarrier) Get(key string) string {
+	return string(c.header.Peek(key))
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.header.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	var keys []string
+	c.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// InjectTraceHeaders writes the trace context of ctx to the outgoing request as w3c traceparent
+// and forwards the trace and request ids of the incoming request
+func InjectTraceHeaders(ctx context.Context, header *fasthttp.RequestHeader) {
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{header: header})
+	if traceID, ok := ctx.Value(CtxKeyTraceID).(string); ok && traceID != "" {
+		header.Set(HeaderXTraceID, traceID)
+	}
+	if requestID, ok := ctx.Value(CtxKeyRequestID).(string); ok && requestID != "" {
+		header.Set(HeaderXRequestID, requestID)
+	}
+}
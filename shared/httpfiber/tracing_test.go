@@ -0,0 +1,66 @@
+package httpfiber
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	const (
+		callerTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		callerSpanID  = "00f067aa0ba902b7"
+	)
+
+	// headers of the outgoing request made while handling the incoming one
+	outgoing := &fasthttp.RequestHeader{}
+	app := fiber.New()
+	app.Use(TracingMiddleware)
+	app.Get("/drivers/:id", func(ctx fiber.Ctx) error {
+		InjectTraceHeaders(ctx.UserContext(), outgoing)
+		return ctx.SendStatus(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/drivers/1", nil)
+	req.Header.Set("traceparent", "00-"+callerTraceID+"-"+callerSpanID+"-01")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got: %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /drivers/:id" {
+		t.Errorf("expected span to be named by route pattern, got: %s", span.Name())
+	}
+	if span.SpanContext().TraceID().String() != callerTraceID || span.Parent().SpanID().String() != callerSpanID {
+		t.Errorf("expected span to continue the trace of the caller, got trace %s parent %s", span.SpanContext().TraceID(), span.Parent().SpanID())
+	}
+
+	if traceID := resp.Header.Get(HeaderXTraceID); traceID != callerTraceID {
+		t.Errorf("expected trace id header to be the otel trace id, got: %s", traceID)
+	}
+	traceparent := string(outgoing.Peek("traceparent"))
+	if !strings.Contains(traceparent, callerTraceID+"-"+span.SpanContext().SpanID().String()) {
+		t.Errorf("expected outgoing traceparent to be a child of the server span, got: %s", traceparent)
+	}
+	if requestID := string(outgoing.Peek(HeaderXRequestID)); requestID == "" || requestID != resp.Header.Get(HeaderXRequestID) {
+		t.Errorf("expected request id to be forwarded, got: %s", requestID)
+	}
+}
@@ -0,0 +1,288 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultJWKSRefreshInterval = time.Hour
+	// minJWKSRefreshInterval limits refetching when tokens are signed with unknown kids
+	minJWKSRefreshInterval = 10 * time.Second
+	jwksFetchTimeout       = 10 * time.Second
+)
+
+var ErrKeyNotFound = errors.New("signing key not found")
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// rsa
+	N string `json:"n"`
+	E string `json:"e"`
+	// ecdsa
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// hmac
+	K string `json:"k"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwkKey struct {
+	alg string
+	key any
+}
+
+// keySet caches the keys of a jwks by kid, keys of a remote jwks are refetched when they
+// are older than refreshInterval or when a kid is not found
+type keySet struct {
+	mu              sync.RWMutex
+	keys            map[string]jwkKey
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	fetchedAt       time.Time
+	lastAttempt     time.Time
+	// refreshes collapses concurrent refreshes into a single fetch
+	refreshes singleflight.Group
+}
+
+func newStaticKeySet(data []byte) (*keySet, error) {
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	return &keySet{keys: keys}, nil
+}
+
+func newRemoteKeySet(url string, refreshInterval time.Duration) *keySet {
+	if refreshInterval <= 0 {
+		refreshInterval = defaultJWKSRefreshInterval
+	}
+	return &keySet{
+		keys:            make(map[string]jwkKey),
+		url:             url,
+		client:          &http.Client{Timeout: jwksFetchTimeout},
+		refreshInterval: refreshInterval,
+	}
+}
+
+func (ks *keySet) keyFunc(method jwt.SigningMethod) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		k, err := ks.lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		if k.alg != "" && k.alg != method.Alg() {
+			return nil, fmt.Errorf("key %q is not usable with %s", kid, method.Alg())
+		}
+		if !keyMatchesMethod(k.key, method) {
+			return nil, fmt.Errorf("key %q does not match %s", kid, method.Alg())
+		}
+		return k.key, nil
+	}
+}
+
+func (ks *keySet) lookup(kid string) (jwkKey, error) {
+	k, ok, stale := ks.get(kid)
+	if ok && !stale {
+		return k, nil
+	}
+	if ks.url == "" {
+		if ok {
+			return k, nil
+		}
+		return jwkKey{}, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+	}
+
+	if err := ks.refresh(); err != nil {
+		// serve the stale key rather than failing while the jwks endpoint is down
+		if ok {
+			return k, nil
+		}
+		return jwkKey{}, err
+	}
+	if k, ok, _ = ks.get(kid); !ok {
+		return jwkKey{}, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+	}
+	return k, nil
+}
+
+// get returns the key of kid, the only key is returned if kid is empty
+func (ks *keySet) get(kid string) (jwkKey, bool, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	stale := ks.url != "" && time.Since(ks.fetchedAt) > ks.refreshInterval
+	if kid == "" {
+		if len(ks.keys) != 1 {
+			return jwkKey{}, false, stale
+		}
+		for _, k := range ks.keys {
+			return k, true, stale
+		}
+	}
+	k, ok := ks.keys[kid]
+	return k, ok, stale
+}
+
+// refresh refetches the keys, the jwks is fetched without holding the lock so that cached keys are
+// served meanwhile and callers refreshing concurrently wait for the same fetch
+func (ks *keySet) refresh() error {
+	_, err, _ := ks.refreshes.Do(ks.url, func() (any, error) {
+		ks.mu.Lock()
+		if time.Since(ks.lastAttempt) < minJWKSRefreshInterval {
+			ks.mu.Unlock()
+			return nil, nil
+		}
+		ks.lastAttempt = time.Now()
+		ks.mu.Unlock()
+
+		keys, err := ks.fetch()
+		if err != nil {
+			return nil, err
+		}
+
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+		ks.keys = keys
+		ks.fetchedAt = time.Now()
+		return nil, nil
+	})
+	return err
+}
+
+func (ks *keySet) fetch() (map[string]jwkKey, error) {
+	resp, err := ks.client.Get(ks.url)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch jwks: unexpected status code %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read jwks: %w", err)
+	}
+	return parseJWKS(data)
+}
+
+// parseJWKS parses the signature keys of a jwks, keys of unknown types are skipped
+func parseJWKS(data []byte) (map[string]jwkKey, error) {
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("could not decode jwks: %w", err)
+	}
+
+	keys := make(map[string]jwkKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk %q: %w", k.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+		keys[k.Kid] = jwkKey{alg: k.Alg, key: key}
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		return decodeBase64URL(k.K)
+	default:
+		return nil, nil
+	}
+}
+
+func keyMatchesMethod(key any, method jwt.SigningMethod) bool {
+	switch m := method.(type) {
+	case *jwt.SigningMethodHMAC:
+		_, ok := key.([]byte)
+		return ok
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok := key.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		k, ok := key.(*ecdsa.PublicKey)
+		return ok && k.Curve.Params().BitSize == m.CurveBits
+	default:
+		return false
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := decodeBase64URL(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("missing key parameter")
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
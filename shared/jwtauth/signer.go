@@ -0,0 +1,94 @@
+package jwtauth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type SignerConfig struct {
+	// Algorithm is the signing algorithm, e.g. HS256, RS256 or ES256
+	Algorithm string
+	// Secret is the hmac secret of HS algorithms, it is read from SecretFile if it is empty
+	Secret     string
+	SecretFile string
+	// PrivateKeyFile is a pem encoded rsa or ecdsa private key
+	PrivateKeyFile string
+	// KeyID is set as kid header so that verifiers can pick the key from a jwks
+	KeyID string
+}
+
+// Signer signs tokens with a single key
+type Signer struct {
+	method jwt.SigningMethod
+	key    any
+	keyID  string
+}
+
+func NewSigner(cfg SignerConfig) (*Signer, error) {
+	method := jwt.GetSigningMethod(cfg.Algorithm)
+	if method == nil || method == jwt.SigningMethodNone {
+		return nil, fmt.Errorf("unsupported signing algorithm: %q", cfg.Algorithm)
+	}
+
+	var key any
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		secret, err := loadSecret(cfg.Secret, cfg.SecretFile)
+		if err != nil {
+			return nil, err
+		}
+		key = []byte(secret)
+	default:
+		if cfg.PrivateKeyFile == "" {
+			return nil, errors.New("no signing key is configured")
+		}
+		data, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read private key file: %w", err)
+		}
+		if key, err = parsePrivateKey(data, method); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Signer{
+		method: method,
+		key:    key,
+		keyID:  cfg.KeyID,
+	}, nil
+}
+
+func (s *Signer) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.method, claims)
+	if s.keyID != "" {
+		token.Header["kid"] = s.keyID
+	}
+	tokenStr, err := token.SignedString(s.key)
+	if err != nil {
+		return "", fmt.Errorf("could not sign token: %w", err)
+	}
+	return tokenStr, nil
+}
+
+// parsePrivateKey parses a pem encoded private key that matches the signing method
+func parsePrivateKey(data []byte, method jwt.SigningMethod) (any, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse rsa private key: %w", err)
+		}
+		return key, nil
+	case *jwt.SigningMethodECDSA:
+		key, err := jwt.ParseECPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse ecdsa private key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("private key can not be used with %s", method.Alg())
+	}
+}
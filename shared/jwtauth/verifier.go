@@ -0,0 +1,162 @@
+package jwtauth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MinSecretLength is the least number of bytes of hmac secrets, the size of a sha256 digest
+const MinSecretLength = 32
+
+// placeholderSecrets are secrets of sample configurations
+var placeholderSecrets = []string{"change-me", "changeme", "secret"}
+
+type Config struct {
+	// Algorithm is the only signing algorithm accepted, e.g. HS256, RS256 or ES256
+	Algorithm string
+	// Secret is the hmac secret of HS algorithms, it is read from SecretFile if it is empty
+	Secret     string
+	SecretFile string
+	// PublicKeyFile is a pem encoded rsa or ecdsa public key
+	PublicKeyFile string
+	// JWKSURL is fetched and cached by kid, keys are refetched every JWKSRefreshInterval
+	// and whenever a token is signed with an unknown kid
+	JWKSURL             string
+	JWKSRefreshInterval time.Duration
+	// JWKSFile is a local jwks, mostly useful for tests
+	JWKSFile string
+	// Issuer and Audience are validated if they are set
+	Issuer   string
+	Audience string
+	// Leeway allows clock skew when validating time based claims
+	Leeway time.Duration
+}
+
+// Verifier parses tokens and verifies their signatures and registered claims
+type Verifier struct {
+	parser  *jwt.Parser
+	keyFunc jwt.Keyfunc
+}
+
+func NewVerifier(cfg Config) (*Verifier, error) {
+	method := jwt.GetSigningMethod(cfg.Algorithm)
+	if method == nil || method == jwt.SigningMethodNone {
+		return nil, fmt.Errorf("unsupported signing algorithm: %q", cfg.Algorithm)
+	}
+
+	keyFunc, err := newKeyFunc(cfg, method)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{method.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &Verifier{
+		parser:  jwt.NewParser(opts...),
+		keyFunc: keyFunc,
+	}, nil
+}
+
+// Parse verifies tokenStr and decodes its claims into claims, an optional Bearer prefix is ignored
+func (v *Verifier) Parse(tokenStr string, claims jwt.Claims) (*jwt.Token, error) {
+	tokenStr = strings.TrimSpace(strings.TrimPrefix(tokenStr, "Bearer "))
+	return v.parser.ParseWithClaims(tokenStr, claims, v.keyFunc)
+}
+
+func newKeyFunc(cfg Config, method jwt.SigningMethod) (jwt.Keyfunc, error) {
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		secret, err := loadSecret(cfg.Secret, cfg.SecretFile)
+		if err != nil {
+			return nil, err
+		}
+		key := []byte(secret)
+		return func(*jwt.Token) (any, error) { return key, nil }, nil
+	}
+
+	switch {
+	case cfg.JWKSURL != "":
+		keySet := newRemoteKeySet(cfg.JWKSURL, cfg.JWKSRefreshInterval)
+		return keySet.keyFunc(method), nil
+	case cfg.JWKSFile != "":
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read jwks file: %w", err)
+		}
+		keySet, err := newStaticKeySet(data)
+		if err != nil {
+			return nil, err
+		}
+		return keySet.keyFunc(method), nil
+	case cfg.PublicKeyFile != "":
+		data, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read public key file: %w", err)
+		}
+		key, err := parsePublicKey(data, method)
+		if err != nil {
+			return nil, err
+		}
+		return func(*jwt.Token) (any, error) { return key, nil }, nil
+	case cfg.Secret != "" || cfg.SecretFile != "":
+		return nil, fmt.Errorf("secret can not be used with %s", method.Alg())
+	default:
+		return nil, errors.New("no verification key is configured")
+	}
+}
+
+// loadSecret returns secret or the content of secretFile, empty, placeholder and short secrets are rejected
+// since anyone knowing them could forge tokens
+func loadSecret(secret, secretFile string) (string, error) {
+	if secret == "" && secretFile != "" {
+		data, err := os.ReadFile(secretFile)
+		if err != nil {
+			return "", fmt.Errorf("could not read secret file: %w", err)
+		}
+		secret = strings.TrimSpace(string(data))
+	}
+	switch {
+	case secret == "":
+		return "", errors.New("no secret is configured")
+	case slices.Contains(placeholderSecrets, strings.ToLower(secret)):
+		return "", errors.New("secret is a placeholder, configure a random secret")
+	case len(secret) < MinSecretLength:
+		return "", fmt.Errorf("secret must be at least %d bytes", MinSecretLength)
+	}
+	return secret, nil
+}
+
+// parsePublicKey parses a pem encoded public key that matches the signing method
+func parsePublicKey(data []byte, method jwt.SigningMethod) (any, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse rsa public key: %w", err)
+		}
+		return key, nil
+	case *jwt.SigningMethodECDSA:
+		key, err := jwt.ParseECPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse ecdsa public key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("public key can not be used with %s", method.Alg())
+	}
+}
@@ -0,0 +1,340 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "bitaksi"
+	testAudience = "matching-api"
+)
+
+func validClaims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Issuer:    testIssuer,
+		Audience:  jwt.ClaimStrings{testAudience},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.Claims, key any) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	tokenStr, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("could not sign token: %v", err)
+	}
+	return tokenStr
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) jwk {
+	return jwk{
+		Kty: "RSA",
+		Kid: kid,
+		Alg: "RS256",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("could not write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestHMACSecret(t *testing.T) {
+	secretFile := writeFile(t, "secret", []byte("test-secret-of-at-least-32-bytes\n"))
+
+	testCases := []struct {
+		name       string
+		secret     string
+		secretFile string
+		expected   bool
+	}{
+		{name: "should accept secret", secret: "test-secret-of-at-least-32-bytes", expected: true},
+		{name: "should read secret file", secretFile: secretFile, expected: true},
+		{name: "should reject empty secret"},
+		{name: "should reject placeholder secret", secret: "change-me"},
+		{name: "should reject short secret", secret: "short-secret"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, verifierErr := NewVerifier(Config{Algorithm: "HS256", Secret: tc.secret, SecretFile: tc.secretFile})
+			_, signerErr := NewSigner(SignerConfig{Algorithm: "HS256", Secret: tc.secret, SecretFile: tc.secretFile})
+			if (verifierErr == nil) != tc.expected || (signerErr == nil) != tc.expected {
+				t.Errorf("expected secret to be accepted: %t, got: %v, %v", tc.expected, verifierErr, signerErr)
+			}
+		})
+	}
+}
+
+func TestVerifierHMAC(t *testing.T) {
+	secret := []byte("test-secret-of-at-least-32-bytes")
+	verifier, err := NewVerifier(Config{
+		Algorithm: "HS256",
+		Secret:    string(secret),
+		Issuer:    testIssuer,
+		Audience:  testAudience,
+	})
+	if err != nil {
+		t.Fatalf("could not create verifier: %v", err)
+	}
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	expired := validClaims()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	wrongIssuer := validClaims()
+	wrongIssuer.Issuer = "someone"
+	wrongAudience := validClaims()
+	wrongAudience.Audience = jwt.ClaimStrings{"other-api"}
+	noExpiry := validClaims()
+	noExpiry.ExpiresAt = nil
+
+	testCases := []struct {
+		name     string
+		token    string
+		expected bool
+	}{
+		{
+			name:     "should accept valid token",
+			token:    sign(t, jwt.SigningMethodHS256, "", validClaims(), secret),
+			expected: true,
+		},
+		{
+			name:     "should accept bearer prefix",
+			token:    "Bearer " + sign(t, jwt.SigningMethodHS256, "", validClaims(), secret),
+			expected: true,
+		},
+		{
+			name:  "should reject wrong secret",
+			token: sign(t, jwt.SigningMethodHS256, "", validClaims(), []byte("forged")),
+		},
+		{
+			name:  "should reject other hmac algorithm",
+			token: sign(t, jwt.SigningMethodHS512, "", validClaims(), secret),
+		},
+		{
+			name:  "should reject rsa signed token",
+			token: sign(t, jwt.SigningMethodRS256, "", validClaims(), rsaKey),
+		},
+		{
+			name:  "should reject unsigned token",
+			token: sign(t, jwt.SigningMethodNone, "", validClaims(), jwt.UnsafeAllowNoneSignatureType),
+		},
+		{
+			name:  "should reject expired token",
+			token: sign(t, jwt.SigningMethodHS256, "", expired, secret),
+		},
+		{
+			name:  "should reject token without expiry",
+			token: sign(t, jwt.SigningMethodHS256, "", noExpiry, secret),
+		},
+		{
+			name:  "should reject wrong issuer",
+			token: sign(t, jwt.SigningMethodHS256, "", wrongIssuer, secret),
+		},
+		{
+			name:  "should reject wrong audience",
+			token: sign(t, jwt.SigningMethodHS256, "", wrongAudience, secret),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := verifier.Parse(tc.token, &jwt.RegisteredClaims{})
+			if tc.expected && err != nil {
+				t.Errorf("expected token to be valid, got: %v", err)
+			}
+			if !tc.expected && err == nil {
+				t.Error("expected token to be rejected")
+			}
+		})
+	}
+}
+
+func TestVerifierPublicKeyFile(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("could not marshal public key: %v", err)
+	}
+	path := writeFile(t, "public.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	verifier, err := NewVerifier(Config{Algorithm: "ES256", PublicKeyFile: path})
+	if err != nil {
+		t.Fatalf("could not create verifier: %v", err)
+	}
+
+	if _, err := verifier.Parse(sign(t, jwt.SigningMethodES256, "", validClaims(), key), &jwt.RegisteredClaims{}); err != nil {
+		t.Errorf("expected token to be valid, got: %v", err)
+	}
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := verifier.Parse(sign(t, jwt.SigningMethodES256, "", validClaims(), other), &jwt.RegisteredClaims{}); err == nil {
+		t.Error("expected token signed by another key to be rejected")
+	}
+}
+
+func TestVerifierJWKSFile(t *testing.T) {
+	first, _ := rsa.GenerateKey(rand.Reader, 2048)
+	second, _ := rsa.GenerateKey(rand.Reader, 2048)
+	data, _ := json.Marshal(jwks{Keys: []jwk{rsaJWK("first", &first.PublicKey), rsaJWK("second", &second.PublicKey)}})
+
+	verifier, err := NewVerifier(Config{Algorithm: "RS256", JWKSFile: writeFile(t, "jwks.json", data)})
+	if err != nil {
+		t.Fatalf("could not create verifier: %v", err)
+	}
+
+	if _, err := verifier.Parse(sign(t, jwt.SigningMethodRS256, "second", validClaims(), second), &jwt.RegisteredClaims{}); err != nil {
+		t.Errorf("expected token to be valid, got: %v", err)
+	}
+	if _, err := verifier.Parse(sign(t, jwt.SigningMethodRS256, "first", validClaims(), second), &jwt.RegisteredClaims{}); err == nil {
+		t.Error("expected token with mismatching kid to be rejected")
+	}
+	if _, err := verifier.Parse(sign(t, jwt.SigningMethodRS256, "", validClaims(), first), &jwt.RegisteredClaims{}); err == nil {
+		t.Error("expected token without kid to be rejected when jwks has many keys")
+	}
+}
+
+func TestVerifierJWKSRotation(t *testing.T) {
+	old, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rotated, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	var rotatedKeys atomic.Bool
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		set := jwks{Keys: []jwk{rsaJWK("old", &old.PublicKey)}}
+		if rotatedKeys.Load() {
+			set.Keys = append(set.Keys, rsaJWK("rotated", &rotated.PublicKey))
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	defer server.Close()
+
+	keySet := newRemoteKeySet(server.URL, 0)
+	parse := func(tokenStr string) error {
+		_, err := jwt.Parse(tokenStr, keySet.keyFunc(jwt.SigningMethodRS256), jwt.WithValidMethods([]string{"RS256"}))
+		return err
+	}
+
+	// keys are fetched lazily and cached
+	for i := 0; i < 3; i++ {
+		if err := parse(sign(t, jwt.SigningMethodRS256, "old", validClaims(), old)); err != nil {
+			t.Fatalf("expected token to be valid, got: %v", err)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("expected jwks to be fetched once, got: %d", fetches.Load())
+	}
+
+	// unknown kid triggers a refetch once the minimum refresh interval passes
+	rotatedKeys.Store(true)
+	rotatedToken := sign(t, jwt.SigningMethodRS256, "rotated", validClaims(), rotated)
+	if err := parse(rotatedToken); err == nil {
+		t.Error("expected unknown kid to be rejected within the minimum refresh interval")
+	}
+	keySet.lastAttempt = time.Time{}
+	if err := parse(rotatedToken); err != nil {
+		t.Errorf("expected rotated key to be fetched, got: %v", err)
+	}
+	if fetches.Load() != 2 {
+		t.Errorf("expected jwks to be fetched twice, got: %d", fetches.Load())
+	}
+
+	// stale keys are served while the jwks endpoint is down
+	server.Close()
+	keySet.lastAttempt = time.Time{}
+	keySet.fetchedAt = time.Now().Add(-2 * defaultJWKSRefreshInterval)
+	if err := parse(rotatedToken); err != nil {
+		t.Errorf("expected stale key to be used, got: %v", err)
+	}
+}
+
+func TestVerifierJWKSConcurrentRefresh(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	data, _ := json.Marshal(jwks{Keys: []jwk{rsaJWK("key", &key.PublicKey)}})
+
+	var fetches atomic.Int32
+	fetching := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) == 1 {
+			close(fetching)
+		}
+		<-release
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	keySet := newRemoteKeySet(server.URL, 0)
+	keySet.keys["cached"] = jwkKey{key: &key.PublicKey}
+	keySet.fetchedAt = time.Now()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := keySet.lookup("key")
+			errs <- err
+		}()
+	}
+
+	// cached keys are served while the jwks is fetched
+	<-fetching
+	lookedUp := make(chan error, 1)
+	go func() {
+		_, err := keySet.lookup("cached")
+		lookedUp <- err
+	}()
+	select {
+	case err := <-lookedUp:
+		if err != nil {
+			t.Errorf("expected cached key, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected cached key to be served during the fetch")
+	}
+
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("expected key to be fetched, got: %v", err)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("expected concurrent refreshes to fetch the jwks once, got: %d", fetches.Load())
+	}
+}
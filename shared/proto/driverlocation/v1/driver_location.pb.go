@@ -0,0 +1,874 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.2
+// 	protoc        v5.28.3
+// source: driverlocation/v1/driver_location.proto
+
+package driverlocationv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DriverStatus int32
+
+const (
+	DriverStatus_DRIVER_STATUS_UNSPECIFIED DriverStatus = 0
+	DriverStatus_DRIVER_STATUS_AVAILABLE   DriverStatus = 1
+	DriverStatus_DRIVER_STATUS_ON_TRIP     DriverStatus = 2
+	DriverStatus_DRIVER_STATUS_OFFLINE     DriverStatus = 3
+	DriverStatus_DRIVER_STATUS_BREAK       DriverStatus = 4
+)
+
+// Enum value maps for DriverStatus.
+var (
+	DriverStatus_name = map[int32]string{
+		0: "DRIVER_STATUS_UNSPECIFIED",
+		1: "DRIVER_STATUS_AVAILABLE",
+		2: "DRIVER_STATUS_ON_TRIP",
+		3: "DRIVER_STATUS_OFFLINE",
+		4: "DRIVER_STATUS_BREAK",
+	}
+	DriverStatus_value = map[string]int32{
+		"DRIVER_STATUS_UNSPECIFIED": 0,
+		"DRIVER_STATUS_AVAILABLE":   1,
+		"DRIVER_STATUS_ON_TRIP":     2,
+		"DRIVER_STATUS_OFFLINE":     3,
+		"DRIVER_STATUS_BREAK":       4,
+	}
+)
+
+func (x DriverStatus) Enum() *DriverStatus {
+	p := new(DriverStatus)
+	*p = x
+	return p
+}
+
+func (x DriverStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DriverStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_driverlocation_v1_driver_location_proto_enumTypes[0].Descriptor()
+}
+
+func (DriverStatus) Type() protoreflect.EnumType {
+	return &file_driverlocation_v1_driver_location_proto_enumTypes[0]
+}
+
+func (x DriverStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DriverStatus.Descriptor instead.
+func (DriverStatus) EnumDescriptor() ([]byte, []int) {
+	return file_driverlocation_v1_driver_location_proto_rawDescGZIP(), []int{0}
+}
+
+// Point is a wgs84 coordinate in degrees
+type Point struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Longitude float64 `protobuf:"fixed64,1,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	Latitude  float64 `protobuf:"fixed64,2,opt,name=latitude,proto3" json:"latitude,omitempty"`
+}
+
+func (x *Point) Reset() {
+	*x = Point{}
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Point) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Point) ProtoMessage() {}
+
+func (x *Point) ProtoReflect() protoreflect.Message {
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Point.ProtoReflect.Descriptor instead.
+func (*Point) Descriptor() ([]byte, []int) {
+	return file_driverlocation_v1_driver_location_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Point) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+func (x *Point) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+type DriverLocation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Point     *Point                 `protobuf:"bytes,2,opt,name=point,proto3" json:"point,omitempty"`
+	Status    DriverStatus           `protobuf:"varint,3,opt,name=status,proto3,enum=driverlocation.v1.DriverStatus" json:"status,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+}
+
+func (x *DriverLocation) Reset() {
+	*x = DriverLocation{}
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DriverLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DriverLocation) ProtoMessage() {}
+
+func (x *DriverLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DriverLocation.ProtoReflect.Descriptor instead.
+func (*DriverLocation) Descriptor() ([]byte, []int) {
+	return file_driverlocation_v1_driver_location_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DriverLocation) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DriverLocation) GetPoint() *Point {
+	if x != nil {
+		return x.Point
+	}
+	return nil
+}
+
+func (x *DriverLocation) GetStatus() DriverStatus {
+	if x != nil {
+		return x.Status
+	}
+	return DriverStatus_DRIVER_STATUS_UNSPECIFIED
+}
+
+func (x *DriverLocation) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type Distance struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Distance float64 `protobuf:"fixed64,1,opt,name=distance,proto3" json:"distance,omitempty"`
+	Unit     string  `protobuf:"bytes,2,opt,name=unit,proto3" json:"unit,omitempty"`
+}
+
+func (x *Distance) Reset() {
+	*x = Distance{}
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Distance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Distance) ProtoMessage() {}
+
+func (x *Distance) ProtoReflect() protoreflect.Message {
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Distance.ProtoReflect.Descriptor instead.
+func (*Distance) Descriptor() ([]byte, []int) {
+	return file_driverlocation_v1_driver_location_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Distance) GetDistance() float64 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+func (x *Distance) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+type DriverDistance struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Location *DriverLocation `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	Distance *Distance       `protobuf:"bytes,2,opt,name=distance,proto3" json:"distance,omitempty"`
+}
+
+func (x *DriverDistance) Reset() {
+	*x = DriverDistance{}
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DriverDistance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DriverDistance) ProtoMessage() {}
+
+func (x *DriverDistance) ProtoReflect() protoreflect.Message {
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DriverDistance.ProtoReflect.Descriptor instead.
+func (*DriverDistance) Descriptor() ([]byte, []int) {
+	return file_driverlocation_v1_driver_location_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DriverDistance) GetLocation() *DriverLocation {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *DriverDistance) GetDistance() *Distance {
+	if x != nil {
+		return x.Distance
+	}
+	return nil
+}
+
+// DriverFilter restricts the searched drivers, available drivers are searched if no status is given
+type DriverFilter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Statuses []DriverStatus `protobuf:"varint,1,rep,packed,name=statuses,proto3,enum=driverlocation.v1.DriverStatus" json:"statuses,omitempty"`
+	// max_age_seconds excludes drivers whose location is older, zero disables the check
+	MaxAgeSeconds int64 `protobuf:"varint,2,opt,name=max_age_seconds,json=maxAgeSeconds,proto3" json:"max_age_seconds,omitempty"`
+}
+
+func (x *DriverFilter) Reset() {
+	*x = DriverFilter{}
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DriverFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DriverFilter) ProtoMessage() {}
+
+func (x *DriverFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DriverFilter.ProtoReflect.Descriptor instead.
+func (*DriverFilter) Descriptor() ([]byte, []int) {
+	return file_driverlocation_v1_driver_location_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DriverFilter) GetStatuses() []DriverStatus {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *DriverFilter) GetMaxAgeSeconds() int64 {
+	if x != nil {
+		return x.MaxAgeSeconds
+	}
+	return 0
+}
+
+type FindNearestDriverRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Point *Point `protobuf:"bytes,1,opt,name=point,proto3" json:"point,omitempty"`
+	// radius is in meters
+	Radius float64       `protobuf:"fixed64,2,opt,name=radius,proto3" json:"radius,omitempty"`
+	Filter *DriverFilter `protobuf:"bytes,3,opt,name=filter,proto3" json:"filter,omitempty"`
+}
+
+func (x *FindNearestDriverRequest) Reset() {
+	*x = FindNearestDriverRequest{}
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindNearestDriverRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindNearestDriverRequest) ProtoMessage() {}
+
+func (x *FindNearestDriverRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindNearestDriverRequest.ProtoReflect.Descriptor instead.
+func (*FindNearestDriverRequest) Descriptor() ([]byte, []int) {
+	return file_driverlocation_v1_driver_location_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FindNearestDriverRequest) GetPoint() *Point {
+	if x != nil {
+		return x.Point
+	}
+	return nil
+}
+
+func (x *FindNearestDriverRequest) GetRadius() float64 {
+	if x != nil {
+		return x.Radius
+	}
+	return 0
+}
+
+func (x *FindNearestDriverRequest) GetFilter() *DriverFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type FindNearestDriverResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Driver *DriverDistance `protobuf:"bytes,1,opt,name=driver,proto3" json:"driver,omitempty"`
+}
+
+func (x *FindNearestDriverResponse) Reset() {
+	*x = FindNearestDriverResponse{}
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindNearestDriverResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindNearestDriverResponse) ProtoMessage() {}
+
+func (x *FindNearestDriverResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindNearestDriverResponse.ProtoReflect.Descriptor instead.
+func (*FindNearestDriverResponse) Descriptor() ([]byte, []int) {
+	return file_driverlocation_v1_driver_location_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FindNearestDriverResponse) GetDriver() *DriverDistance {
+	if x != nil {
+		return x.Driver
+	}
+	return nil
+}
+
+type FindNearestDriversRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Point *Point `protobuf:"bytes,1,opt,name=point,proto3" json:"point,omitempty"`
+	// radius is in meters
+	Radius float64       `protobuf:"fixed64,2,opt,name=radius,proto3" json:"radius,omitempty"`
+	Limit  int32         `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Filter *DriverFilter `protobuf:"bytes,4,opt,name=filter,proto3" json:"filter,omitempty"`
+}
+
+func (x *FindNearestDriversRequest) Reset() {
+	*x = FindNearestDriversRequest{}
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindNearestDriversRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindNearestDriversRequest) ProtoMessage() {}
+
+func (x *FindNearestDriversRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindNearestDriversRequest.ProtoReflect.Descriptor instead.
+func (*FindNearestDriversRequest) Descriptor() ([]byte, []int) {
+	return file_driverlocation_v1_driver_location_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FindNearestDriversRequest) GetPoint() *Point {
+	if x != nil {
+		return x.Point
+	}
+	return nil
+}
+
+func (x *FindNearestDriversRequest) GetRadius() float64 {
+	if x != nil {
+		return x.Radius
+	}
+	return 0
+}
+
+func (x *FindNearestDriversRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *FindNearestDriversRequest) GetFilter() *DriverFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type FindNearestDriversResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Drivers []*DriverDistance `protobuf:"bytes,1,rep,name=drivers,proto3" json:"drivers,omitempty"`
+}
+
+func (x *FindNearestDriversResponse) Reset() {
+	*x = FindNearestDriversResponse{}
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindNearestDriversResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindNearestDriversResponse) ProtoMessage() {}
+
+func (x *FindNearestDriversResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindNearestDriversResponse.ProtoReflect.Descriptor instead.
+func (*FindNearestDriversResponse) Descriptor() ([]byte, []int) {
+	return file_driverlocation_v1_driver_location_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FindNearestDriversResponse) GetDrivers() []*DriverDistance {
+	if x != nil {
+		return x.Drivers
+	}
+	return nil
+}
+
+type UpsertDriverLocationsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Locations []*DriverLocation `protobuf:"bytes,1,rep,name=locations,proto3" json:"locations,omitempty"`
+}
+
+func (x *UpsertDriverLocationsRequest) Reset() {
+	*x = UpsertDriverLocationsRequest{}
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpsertDriverLocationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpsertDriverLocationsRequest) ProtoMessage() {}
+
+func (x *UpsertDriverLocationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpsertDriverLocationsRequest.ProtoReflect.Descriptor instead.
+func (*UpsertDriverLocationsRequest) Descriptor() ([]byte, []int) {
+	return file_driverlocation_v1_driver_location_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpsertDriverLocationsRequest) GetLocations() []*DriverLocation {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
+type UpsertDriverLocationsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *UpsertDriverLocationsResponse) Reset() {
+	*x = UpsertDriverLocationsResponse{}
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpsertDriverLocationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpsertDriverLocationsResponse) ProtoMessage() {}
+
+func (x *UpsertDriverLocationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_driverlocation_v1_driver_location_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpsertDriverLocationsResponse.ProtoReflect.Descriptor instead.
+func (*UpsertDriverLocationsResponse) Descriptor() ([]byte, []int) {
+	return file_driverlocation_v1_driver_location_proto_rawDescGZIP(), []int{10}
+}
+
+var File_driverlocation_v1_driver_location_proto protoreflect.FileDescriptor
+
+var file_driverlocation_v1_driver_location_proto_rawDesc = []byte{
+	0x0a, 0x27, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x2f, 0x76, 0x31, 0x2f, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x5f, 0x6c, 0x6f, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x11, 0x64, 0x72, 0x69, 0x76, 0x65,
+	0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x41, 0x0a,
+	0x05, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69, 0x74,
+	0x75, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69,
+	0x74, 0x75, 0x64, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65,
+	0x22, 0xc4, 0x01, 0x0a, 0x0e, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x4c, 0x6f, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x2e, 0x0a, 0x05, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x18, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x05, 0x70, 0x6f,
+	0x69, 0x6e, 0x74, 0x12, 0x37, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x1f, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f, 0x63, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x39, 0x0a, 0x0a,
+	0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22, 0x3a, 0x0a, 0x08, 0x44, 0x69, 0x73, 0x74, 0x61,
+	0x6e, 0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75,
+	0x6e, 0x69, 0x74, 0x22, 0x88, 0x01, 0x0a, 0x0e, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x44, 0x69,
+	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x3d, 0x0a, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65,
+	0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x72, 0x69,
+	0x76, 0x65, 0x72, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x6c, 0x6f, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x37, 0x0a, 0x08, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72,
+	0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x69, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x52, 0x08, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x22, 0x73,
+	0x0a, 0x0c, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x3b,
+	0x0a, 0x08, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0e,
+	0x32, 0x1f, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x52, 0x08, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x65, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6d,
+	0x61, 0x78, 0x5f, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x6d, 0x61, 0x78, 0x41, 0x67, 0x65, 0x53, 0x65, 0x63, 0x6f,
+	0x6e, 0x64, 0x73, 0x22, 0x9b, 0x01, 0x0a, 0x18, 0x46, 0x69, 0x6e, 0x64, 0x4e, 0x65, 0x61, 0x72,
+	0x65, 0x73, 0x74, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x2e, 0x0a, 0x05, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x05, 0x70, 0x6f, 0x69, 0x6e, 0x74,
+	0x12, 0x16, 0x0a, 0x06, 0x72, 0x61, 0x64, 0x69, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x06, 0x72, 0x61, 0x64, 0x69, 0x75, 0x73, 0x12, 0x37, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74,
+	0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65,
+	0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x72, 0x69,
+	0x76, 0x65, 0x72, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65,
+	0x72, 0x22, 0x56, 0x0a, 0x19, 0x46, 0x69, 0x6e, 0x64, 0x4e, 0x65, 0x61, 0x72, 0x65, 0x73, 0x74,
+	0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x39,
+	0x0a, 0x06, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21,
+	0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e,
+	0x76, 0x31, 0x2e, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x44, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63,
+	0x65, 0x52, 0x06, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x22, 0xb2, 0x01, 0x0a, 0x19, 0x46, 0x69,
+	0x6e, 0x64, 0x4e, 0x65, 0x61, 0x72, 0x65, 0x73, 0x74, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2e, 0x0a, 0x05, 0x70, 0x6f, 0x69, 0x6e, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x69, 0x6e, 0x74,
+	0x52, 0x05, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x61, 0x64, 0x69, 0x75,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x72, 0x61, 0x64, 0x69, 0x75, 0x73, 0x12,
+	0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05,
+	0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x37, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72,
+	0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x22, 0x59,
+	0x0a, 0x1a, 0x46, 0x69, 0x6e, 0x64, 0x4e, 0x65, 0x61, 0x72, 0x65, 0x73, 0x74, 0x44, 0x72, 0x69,
+	0x76, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3b, 0x0a, 0x07,
+	0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e,
+	0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76,
+	0x31, 0x2e, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x44, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
+	0x52, 0x07, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x73, 0x22, 0x5f, 0x0a, 0x1c, 0x55, 0x70, 0x73,
+	0x65, 0x72, 0x74, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x3f, 0x0a, 0x09, 0x6c, 0x6f, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x64,
+	0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31,
+	0x2e, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x09, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x1f, 0x0a, 0x1d, 0x55, 0x70,
+	0x73, 0x65, 0x72, 0x74, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2a, 0x99, 0x01, 0x0a, 0x0c,
+	0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1d, 0x0a, 0x19,
+	0x44, 0x52, 0x49, 0x56, 0x45, 0x52, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e,
+	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x1b, 0x0a, 0x17, 0x44,
+	0x52, 0x49, 0x56, 0x45, 0x52, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x41, 0x56, 0x41,
+	0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x01, 0x12, 0x19, 0x0a, 0x15, 0x44, 0x52, 0x49, 0x56,
+	0x45, 0x52, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4f, 0x4e, 0x5f, 0x54, 0x52, 0x49,
+	0x50, 0x10, 0x02, 0x12, 0x19, 0x0a, 0x15, 0x44, 0x52, 0x49, 0x56, 0x45, 0x52, 0x5f, 0x53, 0x54,
+	0x41, 0x54, 0x55, 0x53, 0x5f, 0x4f, 0x46, 0x46, 0x4c, 0x49, 0x4e, 0x45, 0x10, 0x03, 0x12, 0x17,
+	0x0a, 0x13, 0x44, 0x52, 0x49, 0x56, 0x45, 0x52, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f,
+	0x42, 0x52, 0x45, 0x41, 0x4b, 0x10, 0x04, 0x32, 0xf6, 0x02, 0x0a, 0x15, 0x44, 0x72, 0x69, 0x76,
+	0x65, 0x72, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x12, 0x6e, 0x0a, 0x11, 0x46, 0x69, 0x6e, 0x64, 0x4e, 0x65, 0x61, 0x72, 0x65, 0x73, 0x74,
+	0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x12, 0x2b, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x4e,
+	0x65, 0x61, 0x72, 0x65, 0x73, 0x74, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x2c, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f, 0x63, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x4e, 0x65, 0x61, 0x72,
+	0x65, 0x73, 0x74, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x71, 0x0a, 0x12, 0x46, 0x69, 0x6e, 0x64, 0x4e, 0x65, 0x61, 0x72, 0x65, 0x73, 0x74,
+	0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x73, 0x12, 0x2c, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72,
+	0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x6e, 0x64,
+	0x4e, 0x65, 0x61, 0x72, 0x65, 0x73, 0x74, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2d, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x4e, 0x65,
+	0x61, 0x72, 0x65, 0x73, 0x74, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x7a, 0x0a, 0x15, 0x55, 0x70, 0x73, 0x65, 0x72, 0x74, 0x44, 0x72,
+	0x69, 0x76, 0x65, 0x72, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2f, 0x2e,
+	0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76,
+	0x31, 0x2e, 0x55, 0x70, 0x73, 0x65, 0x72, 0x74, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x4c, 0x6f,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x30,
+	0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e,
+	0x76, 0x31, 0x2e, 0x55, 0x70, 0x73, 0x65, 0x72, 0x74, 0x44, 0x72, 0x69, 0x76, 0x65, 0x72, 0x4c,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x42, 0x59, 0x5a, 0x57, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61,
+	0x6e, 0x69, 0x6c, 0x61, 0x64, 0x61, 0x6e, 0x69, 0x72, 0x2f, 0x62, 0x69, 0x74, 0x61, 0x6b, 0x73,
+	0x69, 0x2d, 0x63, 0x61, 0x73, 0x65, 0x73, 0x74, 0x75, 0x64, 0x79, 0x2f, 0x73, 0x68, 0x61, 0x72,
+	0x65, 0x64, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x6c,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2f, 0x76, 0x31, 0x3b, 0x64, 0x72, 0x69, 0x76, 0x65,
+	0x72, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_driverlocation_v1_driver_location_proto_rawDescOnce sync.Once
+	file_driverlocation_v1_driver_location_proto_rawDescData = file_driverlocation_v1_driver_location_proto_rawDesc
+)
+
+func file_driverlocation_v1_driver_location_proto_rawDescGZIP() []byte {
+	file_driverlocation_v1_driver_location_proto_rawDescOnce.Do(func() {
+		file_driverlocation_v1_driver_location_proto_rawDescData = protoimpl.X.CompressGZIP(file_driverlocation_v1_driver_location_proto_rawDescData)
+	})
+	return file_driverlocation_v1_driver_location_proto_rawDescData
+}
+
+var file_driverlocation_v1_driver_location_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_driverlocation_v1_driver_location_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_driverlocation_v1_driver_location_proto_goTypes = []any{
+	(DriverStatus)(0),                     // 0: driverlocation.v1.DriverStatus
+	(*Point)(nil),                         // 1: driverlocation.v1.Point
+	(*DriverLocation)(nil),                // 2: driverlocation.v1.DriverLocation
+	(*Distance)(nil),                      // 3: driverlocation.v1.Distance
+	(*DriverDistance)(nil),                // 4: driverlocation.v1.DriverDistance
+	(*DriverFilter)(nil),                  // 5: driverlocation.v1.DriverFilter
+	(*FindNearestDriverRequest)(nil),      // 6: driverlocation.v1.FindNearestDriverRequest
+	(*FindNearestDriverResponse)(nil),     // 7: driverlocation.v1.FindNearestDriverResponse
+	(*FindNearestDriversRequest)(nil),     // 8: driverlocation.v1.FindNearestDriversRequest
+	(*FindNearestDriversResponse)(nil),    // 9: driverlocation.v1.FindNearestDriversResponse
+	(*UpsertDriverLocationsRequest)(nil),  // 10: driverlocation.v1.UpsertDriverLocationsRequest
+	(*UpsertDriverLocationsResponse)(nil), // 11: driverlocation.v1.UpsertDriverLocationsResponse
+	(*timestamppb.Timestamp)(nil),         // 12: google.protobuf.Timestamp
+}
+var file_driverlocation_v1_driver_location_proto_depIdxs = []int32{
+	1,  // 0: driverlocation.v1.DriverLocation.point:type_name -> driverlocation.v1.Point
+	0,  // 1: driverlocation.v1.DriverLocation.status:type_name -> driverlocation.v1.DriverStatus
+	12, // 2: driverlocation.v1.DriverLocation.updated_at:type_name -> google.protobuf.Timestamp
+	2,  // 3: driverlocation.v1.DriverDistance.location:type_name -> driverlocation.v1.DriverLocation
+	3,  // 4: driverlocation.v1.DriverDistance.distance:type_name -> driverlocation.v1.Distance
+	0,  // 5: driverlocation.v1.DriverFilter.statuses:type_name -> driverlocation.v1.DriverStatus
+	1,  // 6: driverlocation.v1.FindNearestDriverRequest.point:type_name -> driverlocation.v1.Point
+	5,  // 7: driverlocation.v1.FindNearestDriverRequest.filter:type_name -> driverlocation.v1.DriverFilter
+	4,  // 8: driverlocation.v1.FindNearestDriverResponse.driver:type_name -> driverlocation.v1.DriverDistance
+	1,  // 9: driverlocation.v1.FindNearestDriversRequest.point:type_name -> driverlocation.v1.Point
+	5,  // 10: driverlocation.v1.FindNearestDriversRequest.filter:type_name -> driverlocation.v1.DriverFilter
+	4,  // 11: driverlocation.v1.FindNearestDriversResponse.drivers:type_name -> driverlocation.v1.DriverDistance
+	2,  // 12: driverlocation.v1.UpsertDriverLocationsRequest.locations:type_name -> driverlocation.v1.DriverLocation
+	6,  // 13: driverlocation.v1.DriverLocationService.FindNearestDriver:input_type -> driverlocation.v1.FindNearestDriverRequest
+	8,  // 14: driverlocation.v1.DriverLocationService.FindNearestDrivers:input_type -> driverlocation.v1.FindNearestDriversRequest
+	10, // 15: driverlocation.v1.DriverLocationService.UpsertDriverLocations:input_type -> driverlocation.v1.UpsertDriverLocationsRequest
+	7,  // 16: driverlocation.v1.DriverLocationService.FindNearestDriver:output_type -> driverlocation.v1.FindNearestDriverResponse
+	9,  // 17: driverlocation.v1.DriverLocationService.FindNearestDrivers:output_type -> driverlocation.v1.FindNearestDriversResponse
+	11, // 18: driverlocation.v1.DriverLocationService.UpsertDriverLocations:output_type -> driverlocation.v1.UpsertDriverLocationsResponse
+	16, // [16:19] is the sub-list for method output_type
+	13, // [13:16] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_driverlocation_v1_driver_location_proto_init() }
+func file_driverlocation_v1_driver_location_proto_init() {
+	if File_driverlocation_v1_driver_location_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_driverlocation_v1_driver_location_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_driverlocation_v1_driver_location_proto_goTypes,
+		DependencyIndexes: file_driverlocation_v1_driver_location_proto_depIdxs,
+		EnumInfos:         file_driverlocation_v1_driver_location_proto_enumTypes,
+		MessageInfos:      file_driverlocation_v1_driver_location_proto_msgTypes,
+	}.Build()
+	File_driverlocation_v1_driver_location_proto = out.File
+	file_driverlocation_v1_driver_location_proto_rawDesc = nil
+	file_driverlocation_v1_driver_location_proto_goTypes = nil
+	file_driverlocation_v1_driver_location_proto_depIdxs = nil
+}
@@ -0,0 +1,207 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.3
+// source: driverlocation/v1/driver_location.proto
+
+package driverlocationv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DriverLocationService_FindNearestDriver_FullMethodName     = "/driverlocation.v1.DriverLocationService/FindNearestDriver"
+	DriverLocationService_FindNearestDrivers_FullMethodName    = "/driverlocation.v1.DriverLocationService/FindNearestDrivers"
+	DriverLocationService_UpsertDriverLocations_FullMethodName = "/driverlocation.v1.DriverLocationService/UpsertDriverLocations"
+)
+
+// DriverLocationServiceClient is the client API for DriverLocationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// DriverLocationService finds and updates driver locations
+type DriverLocationServiceClient interface {
+	// FindNearestDriver returns the nearest driver within radius
+	FindNearestDriver(ctx context.Context, in *FindNearestDriverRequest, opts ...grpc.CallOption) (*FindNearestDriverResponse, error)
+	// FindNearestDrivers returns up to limit drivers within radius ordered by distance
+	FindNearestDrivers(ctx context.Context, in *FindNearestDriversRequest, opts ...grpc.CallOption) (*FindNearestDriversResponse, error)
+	// UpsertDriverLocations creates or updates driver locations
+	UpsertDriverLocations(ctx context.Context, in *UpsertDriverLocationsRequest, opts ...grpc.CallOption) (*UpsertDriverLocationsResponse, error)
+}
+
+type driverLocationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDriverLocationServiceClient(cc grpc.ClientConnInterface) DriverLocationServiceClient {
+	return &driverLocationServiceClient{cc}
+}
+
+func (c *driverLocationServiceClient) FindNearestDriver(ctx context.Context, in *FindNearestDriverRequest, opts ...grpc.CallOption) (*FindNearestDriverResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindNearestDriverResponse)
+	err := c.cc.Invoke(ctx, DriverLocationService_FindNearestDriver_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *driverLocationServiceClient) FindNearestDrivers(ctx context.Context, in *FindNearestDriversRequest, opts ...grpc.CallOption) (*FindNearestDriversResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindNearestDriversResponse)
+	err := c.cc.Invoke(ctx, DriverLocationService_FindNearestDrivers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *driverLocationServiceClient) UpsertDriverLocations(ctx context.Context, in *UpsertDriverLocationsRequest, opts ...grpc.CallOption) (*UpsertDriverLocationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpsertDriverLocationsResponse)
+	err := c.cc.Invoke(ctx, DriverLocationService_UpsertDriverLocations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DriverLocationServiceServer is the server API for DriverLocationService service.
+// All implementations must embed UnimplementedDriverLocationServiceServer
+// for forward compatibility.
+//
+// DriverLocationService finds and updates driver locations
+type DriverLocationServiceServer interface {
+	// FindNearestDriver returns the nearest driver within radius
+	FindNearestDriver(context.Context, *FindNearestDriverRequest) (*FindNearestDriverResponse, error)
+	// FindNearestDrivers returns up to limit drivers within radius ordered by distance
+	FindNearestDrivers(context.Context, *FindNearestDriversRequest) (*FindNearestDriversResponse, error)
+	// UpsertDriverLocations creates or updates driver locations
+	UpsertDriverLocations(context.Context, *UpsertDriverLocationsRequest) (*UpsertDriverLocationsResponse, error)
+	mustEmbedUnimplementedDriverLocationServiceServer()
+}
+
+// UnimplementedDriverLocationServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDriverLocationServiceServer struct{}
+
+func (UnimplementedDriverLocationServiceServer) FindNearestDriver(context.Context, *FindNearestDriverRequest) (*FindNearestDriverResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindNearestDriver not implemented")
+}
+func (UnimplementedDriverLocationServiceServer) FindNearestDrivers(context.Context, *FindNearestDriversRequest) (*FindNearestDriversResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindNearestDrivers not implemented")
+}
+func (UnimplementedDriverLocationServiceServer) UpsertDriverLocations(context.Context, *UpsertDriverLocationsRequest) (*UpsertDriverLocationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpsertDriverLocations not implemented")
+}
+func (UnimplementedDriverLocationServiceServer) mustEmbedUnimplementedDriverLocationServiceServer() {}
+func (UnimplementedDriverLocationServiceServer) testEmbeddedByValue()                               {}
+
+// UnsafeDriverLocationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DriverLocationServiceServer will
+// result in compilation errors.
+type UnsafeDriverLocationServiceServer interface {
+	mustEmbedUnimplementedDriverLocationServiceServer()
+}
+
+func RegisterDriverLocationServiceServer(s grpc.ServiceRegistrar, srv DriverLocationServiceServer) {
+	// If the following call pancis, it indicates UnimplementedDriverLocationServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DriverLocationService_ServiceDesc, srv)
+}
+
+func _DriverLocationService_FindNearestDriver_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindNearestDriverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverLocationServiceServer).FindNearestDriver(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DriverLocationService_FindNearestDriver_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverLocationServiceServer).FindNearestDriver(ctx, req.(*FindNearestDriverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DriverLocationService_FindNearestDrivers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindNearestDriversRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverLocationServiceServer).FindNearestDrivers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DriverLocationService_FindNearestDrivers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverLocationServiceServer).FindNearestDrivers(ctx, req.(*FindNearestDriversRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DriverLocationService_UpsertDriverLocations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpsertDriverLocationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverLocationServiceServer).UpsertDriverLocations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DriverLocationService_UpsertDriverLocations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverLocationServiceServer).UpsertDriverLocations(ctx, req.(*UpsertDriverLocationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DriverLocationService_ServiceDesc is the grpc.ServiceDesc for DriverLocationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DriverLocationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "driverlocation.v1.DriverLocationService",
+	HandlerType: (*DriverLocationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "FindNearestDriver",
+			Handler:    _DriverLocationService_FindNearestDriver_Handler,
+		},
+		{
+			MethodName: "FindNearestDrivers",
+			Handler:    _DriverLocationService_FindNearestDrivers_Handler,
+		},
+		{
+			MethodName: "UpsertDriverLocations",
+			Handler:    _DriverLocationService_UpsertDriverLocations_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "driverlocation/v1/driver_location.proto",
+}
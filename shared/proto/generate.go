@@ -0,0 +1,4 @@
+// Package proto contains the protobuf definitions of the service apis and their generated code
+package proto
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative driverlocation/v1/driver_location.proto
@@ -11,6 +11,10 @@ const (
 	ErrCodeInvalidPayload    = "BT-0004"
 	ErrCodeInvalidQueryParam = "BT-0005"
 	ErrCodeBadRequest        = "BT-0006"
+	ErrCodeForbidden         = "BT-0007"
+	ErrCodeDriverIDMismatch  = "BT-0008"
+	ErrCodeTooManyRequests   = "BT-0009"
+	ErrCodeNotReady          = "BT-0010"
 
 	// Messages
 	SuccessMsg             = "Success"
@@ -20,4 +24,8 @@ const (
 	ErrMsgInvalidPayload   = "Invalid Payload"
 	ErrMgInvalidQueryParam = "Invalid Query Params"
 	ErrMsgBadRequest       = "Bad Request"
+	ErrMsgForbidden        = "Forbidden"
+	ErrMsgDriverIDMismatch = "Driver ID Does Not Match Authenticated Driver"
+	ErrMsgTooManyRequests  = "Too Many Requests"
+	ErrMsgNotReady         = "Service Not Ready"
 )
@@ -19,6 +19,7 @@ func getResponse() *Response {
 
 func putResponse(response *Response) {
 	if response != nil {
+		*response = Response{}
 		responsePool.Put(response)
 	}
 }
@@ -48,3 +49,14 @@ func Fail(ctx fiber.Ctx, code, msg string, status int) error {
 	resp.Message = msg
 	return ctx.Status(status).JSON(resp)
 }
+
+// FailWithData responds with an error and data describing it
+func FailWithData(ctx fiber.Ctx, code, msg string, status int, data any) error {
+	resp := getResponse()
+	defer putResponse(resp)
+	resp.Success = false
+	resp.Code = code
+	resp.Message = msg
+	resp.Data = data
+	return ctx.Status(status).JSON(resp)
+}
@@ -0,0 +1,113 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/shared/circuitbreaker"
+)
+
+type Policy struct {
+	// MaxAttempts is the number of attempts including the first one, less than 2 disables retries
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, it grows by Multiplier up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction of the backoff that is randomized, so that clients do not retry in lockstep
+	Jitter float64
+	// IsRetryable classifies the errors worth retrying, IsRetryable of this package is used if it is nil
+	IsRetryable func(err error) bool
+}
+
+// StatusError is returned for responses whose status code may be retried
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+}
+
+// Do calls fn until it succeeds, returns an error that is not retryable or the attempts run out.
+// It stops early if ctx is done or its deadline would pass before the next attempt
+func Do[T any](ctx context.Context, policy Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	isRetryable := policy.IsRetryable
+	if isRetryable == nil {
+		isRetryable = IsRetryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		result, err := fn(ctx)
+		if err == nil || attempt >= policy.MaxAttempts || !isRetryable(err) {
+			return result, err
+		}
+
+		wait := policy.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return result, err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait after the given attempt
+func (p Policy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	wait := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		wait -= wait * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(wait)
+}
+
+// IsRetryable reports whether err is transient: connection errors, timeouts of an attempt and retryable
+// status codes. An open circuit breaker and a done context of the caller are not retried
+func IsRetryable(err error) bool {
+	if errors.Is(err, circuitbreaker.ErrOpen) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return IsRetryableStatus(statusErr.StatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// IsRetryableStatus reports whether a response with the status code may succeed if it is sent again
+func IsRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,132 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aniladanir/bitaksi-casestudy/shared/circuitbreaker"
+)
+
+func TestDo(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2}
+	errPermanent := errors.New("invalid payload")
+
+	testCases := []struct {
+		name             string
+		errs             []error
+		expectedAttempts int
+		expectedErr      error
+	}{
+		{
+			name:             "should not retry success",
+			errs:             []error{nil},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "should retry connection reset",
+			errs:             []error{syscall.ECONNRESET, fmt.Errorf("wrapped: %w", syscall.ECONNRESET), nil},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "should give up after max attempts",
+			errs:             []error{syscall.ECONNRESET, syscall.ECONNRESET, syscall.ECONNRESET, nil},
+			expectedAttempts: 3,
+			expectedErr:      syscall.ECONNRESET,
+		},
+		{
+			name:             "should retry retryable status",
+			errs:             []error{&StatusError{StatusCode: http.StatusServiceUnavailable}, nil},
+			expectedAttempts: 2,
+		},
+		{
+			name:             "should not retry other status",
+			errs:             []error{&StatusError{StatusCode: http.StatusBadRequest}},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "should not retry permanent error",
+			errs:             []error{errPermanent},
+			expectedAttempts: 1,
+			expectedErr:      errPermanent,
+		},
+		{
+			name:             "should not retry open circuit breaker",
+			errs:             []error{circuitbreaker.ErrOpen},
+			expectedAttempts: 1,
+			expectedErr:      circuitbreaker.ErrOpen,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			_, err := Do(context.Background(), policy, func(ctx context.Context) (int, error) {
+				err := tc.errs[attempts]
+				attempts++
+				return attempts, err
+			})
+			if attempts != tc.expectedAttempts {
+				t.Errorf("expected %d attempts, got: %d", tc.expectedAttempts, attempts)
+			}
+			if tc.expectedErr != nil && !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected error %v, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestDoContext(t *testing.T) {
+	policy := Policy{MaxAttempts: 5, InitialBackoff: time.Second}
+
+	// the deadline passes before the next attempt
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	attempts := 0
+	start := time.Now()
+	_, err := Do(ctx, policy, func(ctx context.Context) (int, error) {
+		attempts++
+		return 0, syscall.ECONNREFUSED
+	})
+	if attempts != 1 || !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Errorf("expected single attempt with last error, got: %d %v", attempts, err)
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Errorf("expected not to wait for a backoff past the deadline")
+	}
+
+	// cancellation stops the backoff
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	start = time.Now()
+	Do(ctx, policy, func(ctx context.Context) (int, error) {
+		return 0, syscall.ECONNREFUSED
+	})
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("expected cancellation to stop the backoff")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("expected backoff %v after attempt %d, got: %v", want, i+1, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(2); got < 100*time.Millisecond || got > 200*time.Millisecond {
+			t.Fatalf("expected jittered backoff within [100ms, 200ms], got: %v", got)
+		}
+	}
+}